@@ -0,0 +1,94 @@
+package memcached
+
+import (
+	"context"
+
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+)
+
+// Client is the set of operations shared by the text and binary protocol clients.
+// Every operation has a XxxContext variant, the deadline and cancellation of ctx
+// bound both the connection checkout and the request on the wire.
+type Client interface {
+	Set(e *common.Element) error
+	Add(e *common.Element) error
+	Replace(e *common.Element) error
+	Append(e *common.Element) error
+	Prepend(e *common.Element) error
+	Cas(e *common.Element) error
+	Get(key string) (common.Item, error)
+	GetArray(keys []string) (map[string]common.Item, error)
+	Gets(key string) (common.Item, error)
+	GetsArray(keys []string) (map[string]common.Item, error)
+	Delete(key string) error
+	Incr(key string, value uint64) (uint64, error)
+	Decr(key string, value uint64) (uint64, error)
+	Touch(key string, exptime uint32) error
+	GetAndTouch(key string, exptime uint32) (common.Item, error)
+	GetAndTouchArray(keys []string, exptime uint32) (map[string]common.Item, error)
+	GetsAndTouch(key string, exptime uint32) (common.Item, error)
+	GetsAndTouchArray(keys []string, exptime uint32) (map[string]common.Item, error)
+	Close() error
+	Shutdown(ctx context.Context) error
+	PoolStats() map[string]PoolStats
+
+	// server administration, results are keyed by server address
+	FlushAll(delay uint32) error
+	FlushAllServer(server string, delay uint32) error
+	Version() (map[string]string, error)
+	VersionServer(server string) (string, error)
+	Verbosity(level uint32) error
+	VerbosityServer(server string, level uint32) error
+	Stats(args ...string) (map[string]map[string]string, error)
+	StatsServer(server string, args ...string) (map[string]string, error)
+
+	SetContext(ctx context.Context, e *common.Element) error
+	AddContext(ctx context.Context, e *common.Element) error
+	ReplaceContext(ctx context.Context, e *common.Element) error
+	AppendContext(ctx context.Context, e *common.Element) error
+	PrependContext(ctx context.Context, e *common.Element) error
+	CasContext(ctx context.Context, e *common.Element) error
+	GetContext(ctx context.Context, key string) (common.Item, error)
+	GetArrayContext(ctx context.Context, keys []string) (map[string]common.Item, error)
+	GetsContext(ctx context.Context, key string) (common.Item, error)
+	GetsArrayContext(ctx context.Context, keys []string) (map[string]common.Item, error)
+	DeleteContext(ctx context.Context, key string) error
+	IncrContext(ctx context.Context, key string, value uint64) (uint64, error)
+	DecrContext(ctx context.Context, key string, value uint64) (uint64, error)
+	TouchContext(ctx context.Context, key string, exptime uint32) error
+	GetAndTouchContext(ctx context.Context, key string, exptime uint32) (common.Item, error)
+	GetAndTouchArrayContext(ctx context.Context, keys []string, exptime uint32) (map[string]common.Item, error)
+	GetsAndTouchContext(ctx context.Context, key string, exptime uint32) (common.Item, error)
+	GetsAndTouchArrayContext(ctx context.Context, keys []string, exptime uint32) (map[string]common.Item, error)
+	FlushAllContext(ctx context.Context, delay uint32) error
+	FlushAllServerContext(ctx context.Context, server string, delay uint32) error
+	VersionContext(ctx context.Context) (map[string]string, error)
+	VersionServerContext(ctx context.Context, server string) (string, error)
+	VerbosityContext(ctx context.Context, level uint32) error
+	VerbosityServerContext(ctx context.Context, server string, level uint32) error
+	StatsContext(ctx context.Context, args ...string) (map[string]map[string]string, error)
+	StatsServerContext(ctx context.Context, server string, args ...string) (map[string]string, error)
+}
+
+var (
+	_ Client = (*MemcachedClient4T)(nil)
+	_ Client = (*MemcachedClient4B)(nil)
+)
+
+// New return a client, the protocol is chosen by c.TextOrBinary (0 text, 1 binary).
+func New(c *config.Config) (Client, error) {
+	if c.TextOrBinary == 1 {
+		client, err := NewMemcachedClient4B(c)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+
+	client, err := NewMemcachedClient4T(c)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
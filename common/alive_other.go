@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package common
+
+import "syscall"
+
+// peekAlive is not available on this platform, Alive falls back to a read with a short deadline.
+func peekAlive(rc syscall.RawConn) (alive bool, ok bool) {
+	return false, false
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package common
+
+import "syscall"
+
+// peekAlive peek one byte of the socket with MSG_PEEK|MSG_DONTWAIT, the connection is alive
+// if the read would block, EOF or pending data mean it is not.
+func peekAlive(rc syscall.RawConn) (alive bool, ok bool) {
+	err := rc.Read(func(fd uintptr) bool {
+		var b [1]byte
+		n, _, err := syscall.Recvfrom(int(fd), b[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		alive = n < 0 && (err == syscall.EAGAIN || err == syscall.EWOULDBLOCK)
+		return true
+	})
+
+	return alive, err == nil
+}
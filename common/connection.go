@@ -4,38 +4,159 @@ import (
 	"github.com/ningjh/memcached/config"
 
 	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
 	"net"
+	"strings"
+	"syscall"
 	"time"
 )
 
+// aLongTimeAgo is a deadline in the past, used to interrupt a blocked read or write.
+var aLongTimeAgo = time.Unix(1, 0)
+
 // Conn wrap a net.Conn, and provide a buffer reader and writer
 type Conn struct {
-	Conn   net.Conn
-	RW     *bufio.ReadWriter
-	config *config.Config
-	Index  int
+	Conn      net.Conn
+	RW        *bufio.ReadWriter
+	config    *config.Config
+	server    string //the address of the server, parsed once from the config
+	Index     int
+	CreatedAt time.Time //when the connection was opened
+	UsedAt    time.Time //when the connection was last put back to the pool
+
+	ctx     context.Context
+	stop    chan struct{}
+	stopped chan struct{}
+
+	readDeadline  time.Time //the deadlines set on the socket, so an unchanged one is not set again
+	writeDeadline time.Time
 }
 
 func NewConn(conn net.Conn, c *config.Config, i int) *Conn {
+	now := time.Now()
+
+	var server string
+	if c != nil && i >= 0 && i < len(c.Servers) {
+		server = c.Addr(i)
+	}
+
 	return &Conn{
-		Conn:   conn,
-		RW:     bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)),
-		config: c,
-		Index:  i,
+		Conn:      conn,
+		RW:        bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)),
+		config:    c,
+		server:    server,
+		Index:     i,
+		CreatedAt: now,
+		UsedAt:    now,
 	}
 }
 
+// Bind attach ctx to the connection until Unbind is called.
+// The deadline of ctx bounds every read and write, and cancelling ctx interrupts a blocked one.
+func (c *Conn) Bind(ctx context.Context) {
+	c.ctx = ctx
+
+	if ctx.Done() == nil {
+		return
+	}
+
+	c.stop = make(chan struct{})
+	c.stopped = make(chan struct{})
+
+	go func(conn net.Conn, stop, stopped chan struct{}) {
+		defer close(stopped)
+
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(aLongTimeAgo)
+		case <-stop:
+		}
+	}(c.Conn, c.stop, c.stopped)
+}
+
+// Unbind detach the context attached by Bind.
+// It returns the context's error if the context was done, the connection must not be reused then.
+func (c *Conn) Unbind() (err error) {
+	if c.ctx == nil {
+		return
+	}
+
+	if c.stop != nil {
+		close(c.stop)
+		<-c.stopped
+		c.stop, c.stopped = nil, nil
+	}
+
+	err = c.ctx.Err()
+	c.ctx = nil
+
+	return
+}
+
+// deadline return the earlier of now + timeout (Millisecond) and the deadline of the bound context.
+func (c *Conn) deadline(timeout int64) (t time.Time) {
+	if timeout > 0 {
+		t = time.Now().Add(time.Millisecond * time.Duration(timeout))
+	}
+
+	if c.ctx != nil {
+		if d, ok := c.ctx.Deadline(); ok && (t.IsZero() || d.Before(t)) {
+			t = d
+		}
+	}
+
+	return
+}
+
+// contextError replace err with the error of the bound context if it is done.
+func (c *Conn) contextError(err error) error {
+	if err == nil || c.ctx == nil {
+		return err
+	}
+
+	if e := c.ctx.Err(); e != nil {
+		return e
+	}
+
+	// the socket deadline may expire a moment before the context does
+	if d, ok := c.ctx.Deadline(); ok && !time.Now().Before(d) {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			return context.DeadlineExceeded
+		}
+	}
+
+	return err
+}
+
+// Server return the address of the memcached server the connection talks to.
+func (c *Conn) Server() string {
+	return c.server
+}
+
 // SetReadTimeout set the connect read timeout.
+// The deadline is only set again if it changes, without ReadTimeout a read costs no deadline update.
 func (c *Conn) SetReadTimeout() {
-	if c.config.ReadTimeout > 0 {
-		c.Conn.SetReadDeadline(time.Now().Add(time.Millisecond * time.Duration(c.config.ReadTimeout)))
+	if c.ctx != nil && c.ctx.Err() != nil {
+		return
+	}
+
+	if t := c.deadline(c.config.ReadTimeout); !t.Equal(c.readDeadline) {
+		c.Conn.SetReadDeadline(t)
+		c.readDeadline = t
 	}
 }
 
 // SetWriteTimeout set the connect write timeout.
 func (c *Conn) SetWriteTimeout() {
-	if c.config.WriteTimeout > 0 {
-		c.Conn.SetWriteDeadline(time.Now().Add(time.Millisecond * time.Duration(c.config.WriteTimeout)))
+	if c.ctx != nil && c.ctx.Err() != nil {
+		return
+	}
+
+	if t := c.deadline(c.config.WriteTimeout); !t.Equal(c.writeDeadline) {
+		c.Conn.SetWriteDeadline(t)
+		c.writeDeadline = t
 	}
 }
 
@@ -47,70 +168,132 @@ func (c *Conn) Write(p []byte) (n int, err error) {
 		err = c.RW.Flush()
 	}
 
+	err = c.contextError(err)
+
 	return
 }
 
 // WriteToBuffer writes the contents of p into the buffer.
 func (c *Conn) WriteToBuffer(p []byte) (int, error) {
 	c.SetWriteTimeout()
-	return c.RW.Write(p)
+	n, err := c.RW.Write(p)
+	return n, c.contextError(err)
 }
 
 // Flush writes any buffered data to the underlying Writer.
 func (c *Conn) Flush() error {
-	return c.RW.Flush()
+	c.SetWriteTimeout()
+	return c.contextError(c.RW.Flush())
 }
 
 // Read reads data into p. It returns the number of bytes read into p.
 func (c *Conn) Read(p []byte) (int, error) {
 	c.SetReadTimeout()
-	return c.RW.Read(p)
+	n, err := c.RW.Read(p)
+	return n, c.contextError(err)
+}
+
+// ReadFull reads exactly len(p) bytes into p.
+func (c *Conn) ReadFull(p []byte) (int, error) {
+	c.SetReadTimeout()
+	n, err := io.ReadFull(c.RW, p)
+	return n, c.contextError(err)
 }
 
 // ReadString reads until the first occurrence of delim in the input, returning a string containing the data up to and including the delimiter.
 func (c *Conn) ReadString(delim byte) (string, error) {
 	c.SetReadTimeout()
-	return c.RW.ReadString(delim)
+	s, err := c.RW.ReadString(delim)
+	return s, c.contextError(err)
 }
 
 // ReadByte reads and returns a single byte. If no byte is available, returns an error.
 func (c *Conn) ReadByte() (byte, error) {
 	c.SetReadTimeout()
-	return c.RW.ReadByte()
+	b, err := c.RW.ReadByte()
+	return b, c.contextError(err)
 }
 
 // Close close the connection and release memory.
 func (c *Conn) Close() {
+	c.Unbind()
 	c.Conn.Close()
 	c.Conn = nil
 	c.RW = nil
 	c.config = nil
 }
 
-// Connected check whether the connection is available.
+// Connected check whether the connection is available, with Alive then Ping.
 func (c *Conn) Connected() bool {
-	var b bool = false
+	return c.Alive() && c.Ping()
+}
+
+// Alive check without blocking that the server has not closed the connection
+// and that no unexpected data is waiting to be read.
+func (c *Conn) Alive() bool {
+	if c.Conn == nil || c.RW.Reader.Buffered() > 0 {
+		return false
+	}
+
+	// peek the socket without waiting: nothing to read if it is idle, EOF if the server closed it
+	if sc, ok := c.Conn.(syscall.Conn); ok {
+		if rc, err := sc.SyscallConn(); err == nil {
+			if alive, ok := peekAlive(rc); ok {
+				return alive
+			}
+		}
+	}
+
+	// no raw socket, e.g. TLS, a read with a very short deadline returns a timeout if nothing is there.
+	// A deadline already passed would fail before reading, and never see EOF.
+	c.Conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+	_, err := c.RW.Peek(1)
+	c.Conn.SetReadDeadline(time.Time{})
+	c.readDeadline = time.Time{}
 
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return true
+	}
+
+	return false
+}
+
+// Ping send a no-op to the server and read the reply, version for the text protocol and NOOP for the binary one.
+func (c *Conn) Ping() bool {
 	if c.Conn == nil {
-		return b
+		return false
 	}
 
 	if c.config.TextOrBinary == 0 { // text protocol
-		if _, err := c.Write([]byte("version\r\n")); err == nil {
-			c.ReadString('\n')
-			b = true
-		}
-	} else {                        // binary protocol
-		data := make([]byte, 25)
-		data[0]  = 0x80
-		data[1]  = 0x09
-		data[3]  = 0x01
-		data[11] = 0x01
-		data[24] = 'a'
-		if _, err := c.Write(data); err == nil {
-			b = true
+		if _, err := c.Write([]byte("version\r\n")); err != nil {
+			return false
 		}
+
+		line, err := c.ReadString('\n')
+
+		return err == nil && strings.HasPrefix(line, "VERSION ")
 	}
 
-	return b
-}
\ No newline at end of file
+	// binary protocol
+	header := make([]byte, 24)
+	header[0] = 0x80
+	header[1] = 0x0a
+
+	if _, err := c.Write(header); err != nil {
+		return false
+	}
+
+	if _, err := c.ReadFull(header); err != nil {
+		return false
+	}
+
+	if header[0] != 0x81 || header[1] != 0x0a || binary.BigEndian.Uint16(header[6:8]) != 0 {
+		return false
+	}
+
+	// a NOOP response has no body, skip it anyway
+	body := make([]byte, binary.BigEndian.Uint32(header[8:12]))
+	_, err := c.ReadFull(body)
+
+	return err == nil
+}
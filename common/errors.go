@@ -0,0 +1,123 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the clients, they can be tested with errors.Is.
+// Text response lines and binary status codes map to the same errors.
+var (
+	// ErrCacheMiss the item did not exist (NOT_FOUND, status 0x0001).
+	ErrCacheMiss = errors.New("Memcached : cache miss")
+
+	// ErrNotStored the condition of add, replace, append or prepend wasn't met (NOT_STORED, status 0x0005).
+	ErrNotStored = errors.New("Memcached : item not stored")
+
+	// ErrCASConflict the item has been modified since you last fetched it (EXISTS, status 0x0002).
+	ErrCASConflict = errors.New("Memcached : compare-and-swap conflict")
+
+	// ErrValueTooLarge the value exceeds the item size limit of the server (status 0x0003).
+	ErrValueTooLarge = errors.New("Memcached : value too large")
+
+	// ErrNonNumeric incr or decr on a non-numeric value (status 0x0006).
+	ErrNonNumeric = errors.New("Memcached : incr/decr on non-numeric value")
+
+	// ErrUnknownCommand the server does not know the command (ERROR, status 0x0081).
+	ErrUnknownCommand = errors.New("Memcached : unknown command")
+
+	// ErrNotSupported the server does not support the command (status 0x0083).
+	ErrNotSupported = errors.New("Memcached : not supported")
+
+	// ErrAuthFailed the server rejected the credentials (status 0x0008 or 0x0020).
+	ErrAuthFailed = errors.New("Memcached : authentication error")
+
+	// ErrAuthContinue the authentication needs another step (status 0x0009 or 0x0021).
+	ErrAuthContinue = errors.New("Memcached : authentication continue")
+
+	// ErrMalformedKey the key is empty, longer than 250 bytes or contains whitespace or control characters.
+	ErrMalformedKey = errors.New("Memcached : malformed key")
+
+	// ErrMalformedResponse the response from the server could not be parsed.
+	ErrMalformedResponse = errors.New("Memcached : malformed response")
+
+	// ErrNilElement a nil *Element was passed to a storage command.
+	ErrNilElement = errors.New("Memcached : nil pointer error")
+
+	// ErrNoServers the configuration has no server.
+	ErrNoServers = errors.New("Memcached : Servers must not empty")
+
+	// ErrNoAvailableServer every server is marked down.
+	ErrNoAvailableServer = errors.New("Memcached : could not found a server")
+
+	// ErrTooFewServers fewer than MinHealthyServers servers were reachable at startup.
+	ErrTooFewServers = errors.New("Memcached : too few healthy servers")
+
+	// ErrServerUnreachable the server could not be connected.
+	ErrServerUnreachable = errors.New("Memcached : can not connect to Memcached server")
+
+	// ErrUnknownServer the server is not in the configuration.
+	ErrUnknownServer = errors.New("Memcached : unknown server")
+
+	// ErrPoolTimeout MaxOpenConns connections were in use for longer than WaitTimeout.
+	ErrPoolTimeout = errors.New("Memcached : timed out waiting for a connection")
+
+	// ErrClientClosed the client, its pool or its selector has been closed.
+	ErrClientClosed = errors.New("Memcached : client closed")
+
+	// ErrServerNodesModified the server list changed in the middle of a request.
+	ErrServerNodesModified = errors.New("Memcached : server nodes had been modified")
+)
+
+// maxKeyLength the longest key memcached accepts.
+const maxKeyLength = 250
+
+// ErrServerError a failure reported by the server (SERVER_ERROR, or a binary status such as out of memory).
+type ErrServerError struct {
+	Server string
+	Msg    string
+}
+
+func (e *ErrServerError) Error() string {
+	return fmt.Sprintf("Memcached : server error from %s : %s", e.Server, e.Msg)
+}
+
+// ErrClientError the server rejected the request as malformed (CLIENT_ERROR, status 0x0004).
+type ErrClientError struct {
+	Server string
+	Msg    string
+}
+
+func (e *ErrClientError) Error() string {
+	return fmt.Sprintf("Memcached : client error from %s : %s", e.Server, e.Msg)
+}
+
+// ErrAuthError the SASL authentication of a new connection failed, errors.Is(err, ErrAuthFailed) is true.
+type ErrAuthError struct {
+	Server    string
+	Mechanism string
+	Msg       string
+}
+
+func (e *ErrAuthError) Error() string {
+	return fmt.Sprintf("Memcached : %s authentication with %s failed : %s", e.Mechanism, e.Server, e.Msg)
+}
+
+func (e *ErrAuthError) Unwrap() error {
+	return ErrAuthFailed
+}
+
+// CheckKey return ErrMalformedKey if key can not be sent to memcached.
+func CheckKey(key string) error {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return ErrMalformedKey
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return ErrMalformedKey
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MetaFlags builds the flags of a meta command (mg, ms, md, ma), for example
+//
+//	NewMetaFlags().Value().ReturnTTL().ReturnCas()
+//
+// Each method appends one flag and returns f, so the calls can be chained.
+type MetaFlags struct {
+	tokens []string
+}
+
+// NewMetaFlags return an empty set of flags.
+func NewMetaFlags() *MetaFlags {
+	return &MetaFlags{}
+}
+
+func (f *MetaFlags) add(flag byte, token string) *MetaFlags {
+	f.tokens = append(f.tokens, string(flag)+token)
+	return f
+}
+
+// Value return the value of the item (v).
+func (f *MetaFlags) Value() *MetaFlags { return f.add('v', "") }
+
+// ReturnCas return the cas value of the item (c).
+func (f *MetaFlags) ReturnCas() *MetaFlags { return f.add('c', "") }
+
+// ReturnFlags return the client flags of the item (f).
+func (f *MetaFlags) ReturnFlags() *MetaFlags { return f.add('f', "") }
+
+// ReturnTTL return the remaining time to live of the item in seconds, -1 for infinite (t).
+func (f *MetaFlags) ReturnTTL() *MetaFlags { return f.add('t', "") }
+
+// ReturnLastAccess return the seconds since the item was last accessed (l).
+func (f *MetaFlags) ReturnLastAccess() *MetaFlags { return f.add('l', "") }
+
+// ReturnHit return whether the item had been hit before this request (h).
+func (f *MetaFlags) ReturnHit() *MetaFlags { return f.add('h', "") }
+
+// ReturnSize return the size of the value (s).
+func (f *MetaFlags) ReturnSize() *MetaFlags { return f.add('s', "") }
+
+// ReturnKey return the key of the item (k).
+func (f *MetaFlags) ReturnKey() *MetaFlags { return f.add('k', "") }
+
+// Opaque return token with the response (O).
+func (f *MetaFlags) Opaque(token string) *MetaFlags { return f.add('O', token) }
+
+// NoReply suppress the uninteresting responses, EN for mg and HD for the others (q).
+func (f *MetaFlags) NoReply() *MetaFlags { return f.add('q', "") }
+
+// NoBump do not bump the item in the LRU (u).
+func (f *MetaFlags) NoBump() *MetaFlags { return f.add('u', "") }
+
+// Base64Key the key is base64 encoded (b).
+func (f *MetaFlags) Base64Key() *MetaFlags { return f.add('b', "") }
+
+// TTL update or set the time to live of the item (T).
+func (f *MetaFlags) TTL(seconds uint32) *MetaFlags {
+	return f.add('T', strconv.FormatUint(uint64(seconds), 10))
+}
+
+// CompareCas only apply the command if the cas value of the item matches (C).
+func (f *MetaFlags) CompareCas(cas uint64) *MetaFlags {
+	return f.add('C', strconv.FormatUint(cas, 10))
+}
+
+// ClientFlags set the client flags of the item, ms only (F).
+func (f *MetaFlags) ClientFlags(flags uint32) *MetaFlags {
+	return f.add('F', strconv.FormatUint(uint64(flags), 10))
+}
+
+// Vivify on miss create an empty item with this time to live and win the right to fill it, mg only (N).
+func (f *MetaFlags) Vivify(seconds uint32) *MetaFlags {
+	return f.add('N', strconv.FormatUint(uint64(seconds), 10))
+}
+
+// Recache win the right to recache if the remaining time to live is below seconds, mg only (R).
+func (f *MetaFlags) Recache(seconds uint32) *MetaFlags {
+	return f.add('R', strconv.FormatUint(uint64(seconds), 10))
+}
+
+// Invalidate mark the item stale instead of removing it (md), or
+// succeed on a stale item whose cas is older than the one given (ms) (I).
+func (f *MetaFlags) Invalidate() *MetaFlags { return f.add('I', "") }
+
+// Mode set the mode of ms (E add, A append, P prepend, R replace, S set)
+// or ma (I or + increment, D or - decrement) (M).
+func (f *MetaFlags) Mode(mode byte) *MetaFlags { return f.add('M', string(mode)) }
+
+// Delta the amount to increment or decrement by, ma only (D).
+func (f *MetaFlags) Delta(delta uint64) *MetaFlags {
+	return f.add('D', strconv.FormatUint(delta, 10))
+}
+
+// InitialValue the value of an item created by ma on miss, ma only (J).
+func (f *MetaFlags) InitialValue(value uint64) *MetaFlags {
+	return f.add('J', strconv.FormatUint(value, 10))
+}
+
+// Has report whether the flag is set.
+func (f *MetaFlags) Has(flag byte) bool {
+	if f == nil {
+		return false
+	}
+
+	for _, t := range f.tokens {
+		if t[0] == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String return the flags as they are sent on the command line.
+func (f *MetaFlags) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return strings.Join(f.tokens, " ")
+}
+
+// MetaResult the response of a meta command and the flags it returned.
+type MetaResult struct {
+	Status     string //VA, HD, EN, NS, EX, NF or MN
+	Key        string
+	Value      []byte
+	Flags      uint32
+	Cas        uint64
+	TTL        int64  //seconds, -1 for infinite
+	LastAccess uint64 //seconds
+	HitBefore  bool
+	Size       uint64
+	Opaque     string
+	Win        bool //W, this client won the right to recache the item
+	Stale      bool //X, the item is stale
+	AlreadyWon bool //Z, another client already won the right to recache the item
+}
+
+// Hit report whether mg found the item.
+func (r *MetaResult) Hit() bool {
+	return r.Status == "VA" || r.Status == "HD"
+}
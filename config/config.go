@@ -1,14 +1,64 @@
 package config
 
+import (
+	"context"
+	"crypto/tls"
+	"net"
+)
+
+// Dialer open a connection to addr, network is "tcp" or "unix".
+type Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// HashFunc hash a key or a virtual node to a point of the ring, see package selector for the implementations.
+type HashFunc func(key string) uint64
+
+// ServerSelector choose the server of a key among the servers that are up.
+// Servers are designated by their index in the list given to SetServers.
+// The implementations live in package selector, they must be safe for concurrent use.
+// A selector that also implements io.Closer is closed by the pool.
+type ServerSelector interface {
+	// SetServers replace the servers, every server is up
+	SetServers(servers ...string) error
+	// PickServer return the server of key, ErrNoAvailableServer if every server is down
+	PickServer(key string) (int, error)
+	// Each call fn for every server in order until fn returns an error
+	Each(fn func(i int, server string, up bool) error) error
+	MarkDown(i int)
+	MarkUp(i int)
+}
+
 // Config the connection pool configuration.
 type Config struct {
-	Servers                     []string //memcached servers
-	InitConns                   uint16   //connect pool size of each server
-	ReadTimeout                 int64    //Millisecond
-	WriteTimeout                int64    //Millisecond
-	NumberOfReplicas            int      //number of replicas of each memcached server
+	Servers                     []string       //memcached servers, "host:port" or "unix:///path/to/socket", optionally followed by " weight=3"
+	InitConns                   uint16         //connect pool size of each server
+	ReadTimeout                 int64          //Millisecond
+	WriteTimeout                int64          //Millisecond
+	NumberOfReplicas            int            //number of replicas of each memcached server
+	Ketama                      bool           //libketama compatible ring, MD5 points of "<server>-<k>", NumberOfReplicas is ignored
+	KetamaPoints                int            //points of each server with Ketama, 0 for 160 as libketama
+	HashFunc                    HashFunc       //hash of the ring, nil for selector.CRC32, ignored with Ketama
+	Selector                    ServerSelector //choose the server of a key, nil for the consistent hashing ring, not shared between clients
 	RefreshHashIntervalInSecond int
 	TextOrBinary                int
+	Username                    string //SASL PLAIN credentials, binary protocol only
+	Password                    string
+	TLS                         *tls.Config            //TLS of every server, nil for plain tcp
+	ServerTLS                   map[string]*tls.Config //TLS of a server keyed by address, overrides TLS, a nil value disables TLS
+	TLSHandshakeTimeout         int64                  //Millisecond
+	Dialer                      Dialer                 //open the connections instead of net.Dialer, nil for the default
+	DialTimeout                 int64                  //Millisecond, bounds the dial of a connection
+	KeepAlive                   int64                  //Millisecond, TCP keep-alive period, 0 for the default of 15 seconds, negative disables
+	NoDelay                     *bool                  //TCP_NODELAY of the tcp connections, nil leaves the default (enabled)
+	InitTimeout                 int64                  //Millisecond, bounds the pre-fill of the connection pools in pool.New
+	LazyStart                   bool                   //servers unreachable at startup are marked down instead of failing pool.New
+	MinHealthyServers           int                    //with LazyStart, pool.New fails if fewer servers are reachable
+	MaxOpenConns                int                    //open connections of each server, 0 for no limit
+	MaxIdleConns                int                    //idle connections kept for each server, 0 for InitConns
+	WaitTimeout                 int64                  //Millisecond, bounds the wait for a connection when MaxOpenConns are in use
+	IdleTimeout                 int64                  //Millisecond, idle connections are closed after it, 0 keeps them
+	MaxConnLifetime             int64                  //Millisecond, connections are closed once this old, 0 keeps them
+	MinIdleConns                int                    //idle connections the reaper keeps open for each server
+	HealthCheckAfter            int64                  //Millisecond, a connection idle longer is checked before reuse, 0 for 1 second, negative never
 }
 
 func New() *Config {
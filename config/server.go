@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseServer split an entry of Servers, "host:port weight=3", into its address and its weight.
+// The weight defaults to 1.
+func ParseServer(server string) (addr string, weight int, err error) {
+	fields := strings.Fields(server)
+	if len(fields) == 0 {
+		return "", 0, fmt.Errorf("Memcached : empty server")
+	}
+
+	addr, weight = fields[0], 1
+
+	for _, f := range fields[1:] {
+		v := strings.TrimPrefix(f, "weight=")
+		if v == f {
+			return "", 0, fmt.Errorf("Memcached : unknown option %q of server %s", f, addr)
+		}
+
+		if weight, err = strconv.Atoi(v); err != nil || weight < 1 {
+			return "", 0, fmt.Errorf("Memcached : invalid weight %q of server %s", v, addr)
+		}
+	}
+
+	return addr, weight, nil
+}
+
+// Addr return the address of the i-th server, without its weight
+func (c *Config) Addr(i int) string {
+	addr, _, _ := ParseServer(c.Servers[i])
+	return addr
+}
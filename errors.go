@@ -0,0 +1,39 @@
+package memcached
+
+import (
+	"github.com/ningjh/memcached/common"
+)
+
+// Errors returned by the clients, see package common for their meaning.
+// Compare them with errors.Is, and use errors.As for *ErrServerError, *ErrClientError and *ErrAuthError.
+var (
+	ErrCacheMiss           = common.ErrCacheMiss
+	ErrNotStored           = common.ErrNotStored
+	ErrCASConflict         = common.ErrCASConflict
+	ErrValueTooLarge       = common.ErrValueTooLarge
+	ErrNonNumeric          = common.ErrNonNumeric
+	ErrUnknownCommand      = common.ErrUnknownCommand
+	ErrNotSupported        = common.ErrNotSupported
+	ErrAuthFailed          = common.ErrAuthFailed
+	ErrAuthContinue        = common.ErrAuthContinue
+	ErrMalformedKey        = common.ErrMalformedKey
+	ErrMalformedResponse   = common.ErrMalformedResponse
+	ErrNilElement          = common.ErrNilElement
+	ErrNoServers           = common.ErrNoServers
+	ErrNoAvailableServer   = common.ErrNoAvailableServer
+	ErrServerUnreachable   = common.ErrServerUnreachable
+	ErrTooFewServers       = common.ErrTooFewServers
+	ErrServerNodesModified = common.ErrServerNodesModified
+	ErrClientClosed        = common.ErrClientClosed
+	ErrPoolTimeout         = common.ErrPoolTimeout
+	ErrUnknownServer       = common.ErrUnknownServer
+)
+
+// ErrServerError a failure reported by the server.
+type ErrServerError = common.ErrServerError
+
+// ErrClientError the server rejected the request as malformed.
+type ErrClientError = common.ErrClientError
+
+// ErrAuthError the SASL authentication of a new connection failed.
+type ErrAuthError = common.ErrAuthError
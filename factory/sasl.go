@@ -0,0 +1,115 @@
+package factory
+
+import (
+	"github.com/ningjh/memcached/common"
+
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const (
+	// binary protocol header, see package parse
+	saslHeaderLen       = 24
+	saslReqMagic  uint8 = 0x80
+	saslResMagic  uint8 = 0x81
+
+	// SASL opcodes
+	saslListMechs uint8 = 0x20
+	saslAuth      uint8 = 0x21
+
+	// SASL status codes
+	saslAuthError    uint16 = 0x20
+	saslAuthContinue uint16 = 0x21
+	unknownCommand   uint16 = 0x81
+
+	plain = "PLAIN"
+)
+
+// authenticate run SASL PLAIN on a new binary connection: list the mechanisms of the server, then send the credentials.
+func (cf *ConnectionFactory) authenticate(ctx context.Context, conn *common.Conn) error {
+	conn.Bind(ctx)
+	defer conn.Unbind()
+
+	status, mechs, err := saslRequest(conn, saslListMechs, "", "")
+	if err != nil {
+		return err
+	}
+
+	if status != 0 {
+		return &common.ErrAuthError{Server: conn.Server(), Mechanism: plain, Msg: saslStatus(status, mechs)}
+	}
+
+	if !strings.Contains(" "+mechs+" ", " "+plain+" ") {
+		return &common.ErrAuthError{Server: conn.Server(), Mechanism: plain, Msg: "mechanism not offered, server offers " + mechs}
+	}
+
+	status, msg, err := saslRequest(conn, saslAuth, plain, "\x00"+cf.config.Username+"\x00"+cf.config.Password)
+	if err != nil {
+		return err
+	}
+
+	if status != 0 {
+		return &common.ErrAuthError{Server: conn.Server(), Mechanism: plain, Msg: saslStatus(status, msg)}
+	}
+
+	return nil
+}
+
+// saslRequest send a request packet and return the status and value of the response
+func saslRequest(conn *common.Conn, opcode uint8, key, value string) (status uint16, body string, err error) {
+	request := make([]byte, saslHeaderLen+len(key)+len(value))
+	request[0] = saslReqMagic
+	request[1] = opcode
+	binary.BigEndian.PutUint16(request[2:4], uint16(len(key)))
+	binary.BigEndian.PutUint32(request[8:12], uint32(len(key)+len(value)))
+	copy(request[saslHeaderLen:], key)
+	copy(request[saslHeaderLen+len(key):], value)
+
+	if _, err = conn.Write(request); err != nil {
+		return
+	}
+
+	header := make([]byte, saslHeaderLen)
+	if _, err = conn.ReadFull(header); err != nil {
+		return
+	}
+
+	if header[0] != saslResMagic {
+		err = common.ErrMalformedResponse
+		return
+	}
+
+	response := make([]byte, binary.BigEndian.Uint32(header[8:12]))
+	if _, err = conn.ReadFull(response); err != nil {
+		return
+	}
+
+	// skip the extras and the key
+	skip := int(header[4]) + int(binary.BigEndian.Uint16(header[2:4]))
+	if skip > len(response) {
+		err = common.ErrMalformedResponse
+		return
+	}
+
+	return binary.BigEndian.Uint16(header[6:8]), string(response[skip:]), nil
+}
+
+// saslStatus describe a failed SASL response
+func saslStatus(status uint16, msg string) string {
+	switch status {
+	case saslAuthError:
+		return "invalid credentials"
+	case saslAuthContinue:
+		return "unexpected authentication step"
+	case unknownCommand:
+		return "SASL not supported by the server"
+	}
+
+	if msg != "" {
+		return msg
+	}
+
+	return fmt.Sprintf("status 0x%04x", status)
+}
@@ -4,9 +4,15 @@ import (
 	"github.com/ningjh/memcached/common"
 	"github.com/ningjh/memcached/config"
 
+	"context"
 	"net"
+	"strings"
+	"time"
 )
 
+// unixPrefix of the servers listening on a unix domain socket
+const unixPrefix = "unix://"
+
 // ConnectionFactory a factory create connection
 type ConnectionFactory struct {
 	config *config.Config
@@ -14,12 +20,62 @@ type ConnectionFactory struct {
 
 // NewTcpConnect create a tcp connection
 func (cf *ConnectionFactory) NewTcpConnect(addr string, i int) (conn *common.Conn, err error) {
-	tcpConn, err := net.Dial("tcp", addr)
+	return cf.NewTcpConnectContext(context.Background(), addr, i)
+}
+
+// NewTcpConnectContext create a connection to addr, the dial is bounded by ctx.
+// With TLS in the config the handshake is done first, with a Username a binary connection
+// is then authenticated with SASL PLAIN before it is returned.
+func (cf *ConnectionFactory) NewTcpConnectContext(ctx context.Context, addr string, i int) (conn *common.Conn, err error) {
+	tcpConn, err := cf.dial(ctx, addr)
+
+	if err == nil {
+		if tlsConfig := cf.tlsConfig(addr); tlsConfig != nil {
+			tcpConn, err = cf.handshake(ctx, tcpConn, tlsConfig)
+		}
+	}
 
 	if err == nil {
 		conn = common.NewConn(tcpConn, cf.config, i)
 	}
 
+	if err == nil && cf.config.TextOrBinary == 1 && cf.config.Username != "" {
+		if err = cf.authenticate(ctx, conn); err != nil {
+			conn.Close()
+			conn = nil
+		}
+	}
+
+	return
+}
+
+// dial open a connection to addr, a "unix://" prefix selects a unix domain socket.
+// The dial is bounded by DialTimeout, KeepAlive and NoDelay apply to tcp connections.
+func (cf *ConnectionFactory) dial(ctx context.Context, addr string) (conn net.Conn, err error) {
+	network := "tcp"
+	if strings.HasPrefix(addr, unixPrefix) {
+		network, addr = "unix", strings.TrimPrefix(addr, unixPrefix)
+	}
+
+	if cf.config.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Millisecond*time.Duration(cf.config.DialTimeout))
+		defer cancel()
+	}
+
+	if cf.config.Dialer != nil {
+		conn, err = cf.config.Dialer(ctx, network, addr)
+	} else {
+		dialer := net.Dialer{KeepAlive: time.Millisecond * time.Duration(cf.config.KeepAlive)}
+		conn, err = dialer.DialContext(ctx, network, addr)
+	}
+
+	if err == nil && cf.config.NoDelay != nil {
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.SetNoDelay(*cf.config.NoDelay)
+		}
+	}
+
 	return
 }
 
@@ -0,0 +1,48 @@
+package factory
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// tlsConfig return the TLS config of addr, nil for plain tcp.
+// The server name defaults to the host of addr, so SNI and the certificate check use it.
+func (cf *ConnectionFactory) tlsConfig(addr string) *tls.Config {
+	c := cf.config.TLS
+	if sc, ok := cf.config.ServerTLS[addr]; ok {
+		c = sc
+	}
+
+	if c == nil {
+		return nil
+	}
+
+	if c.ServerName == "" {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			c = c.Clone()
+			c.ServerName = host
+		}
+	}
+
+	return c
+}
+
+// handshake run the TLS handshake on conn, bounded by ctx and TLSHandshakeTimeout
+func (cf *ConnectionFactory) handshake(ctx context.Context, conn net.Conn, c *tls.Config) (net.Conn, error) {
+	if cf.config.TLSHandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Millisecond*time.Duration(cf.config.TLSHandshakeTimeout))
+		defer cancel()
+	}
+
+	tlsConn := tls.Client(conn, c)
+
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
@@ -0,0 +1,191 @@
+package memcached
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+)
+
+// serverCommands is implemented by the text and the binary protocol parsers,
+// i is the index of a server in config.Servers.
+type serverCommands interface {
+	FlushAll(ctx context.Context, i int, delay uint32) error
+	Version(ctx context.Context, i int) (string, error)
+	Verbosity(ctx context.Context, i int, level uint32) error
+	Stats(ctx context.Context, i int, args ...string) (map[string]string, error)
+}
+
+// admin implements the server administration commands of both clients.
+// The XxxServer methods target one server, the others fan out to every server of config.Servers.
+type admin struct {
+	commands serverCommands
+	config   *config.Config
+}
+
+// FlushAll invalidate all existing items of every server, after delay seconds
+func (a *admin) FlushAll(delay uint32) error {
+	return a.FlushAllContext(context.Background(), delay)
+}
+
+// FlushAllContext is like FlushAll, ctx bounds the request
+func (a *admin) FlushAllContext(ctx context.Context, delay uint32) error {
+	return a.each(func(i int) error {
+		return a.commands.FlushAll(ctx, i, delay)
+	})
+}
+
+// FlushAllServer invalidate all existing items of server, after delay seconds
+func (a *admin) FlushAllServer(server string, delay uint32) error {
+	return a.FlushAllServerContext(context.Background(), server, delay)
+}
+
+// FlushAllServerContext is like FlushAllServer, ctx bounds the request
+func (a *admin) FlushAllServerContext(ctx context.Context, server string, delay uint32) error {
+	i, err := a.serverIndex(server)
+	if err != nil {
+		return err
+	}
+
+	return a.commands.FlushAll(ctx, i, delay)
+}
+
+// Version return the version string of every server, keyed by server address
+func (a *admin) Version() (map[string]string, error) {
+	return a.VersionContext(context.Background())
+}
+
+// VersionContext is like Version, ctx bounds the request
+func (a *admin) VersionContext(ctx context.Context) (map[string]string, error) {
+	var mu sync.Mutex
+	versions := make(map[string]string)
+
+	err := a.each(func(i int) error {
+		v, err := a.commands.Version(ctx, i)
+		if err == nil {
+			mu.Lock()
+			versions[a.config.Addr(i)] = v
+			mu.Unlock()
+		}
+		return err
+	})
+
+	return versions, err
+}
+
+// VersionServer return the version string of server
+func (a *admin) VersionServer(server string) (string, error) {
+	return a.VersionServerContext(context.Background(), server)
+}
+
+// VersionServerContext is like VersionServer, ctx bounds the request
+func (a *admin) VersionServerContext(ctx context.Context, server string) (string, error) {
+	i, err := a.serverIndex(server)
+	if err != nil {
+		return "", err
+	}
+
+	return a.commands.Version(ctx, i)
+}
+
+// Verbosity set the verbosity level of the logging output of every server
+func (a *admin) Verbosity(level uint32) error {
+	return a.VerbosityContext(context.Background(), level)
+}
+
+// VerbosityContext is like Verbosity, ctx bounds the request
+func (a *admin) VerbosityContext(ctx context.Context, level uint32) error {
+	return a.each(func(i int) error {
+		return a.commands.Verbosity(ctx, i, level)
+	})
+}
+
+// VerbosityServer set the verbosity level of the logging output of server
+func (a *admin) VerbosityServer(server string, level uint32) error {
+	return a.VerbosityServerContext(context.Background(), server, level)
+}
+
+// VerbosityServerContext is like VerbosityServer, ctx bounds the request
+func (a *admin) VerbosityServerContext(ctx context.Context, server string, level uint32) error {
+	i, err := a.serverIndex(server)
+	if err != nil {
+		return err
+	}
+
+	return a.commands.Verbosity(ctx, i, level)
+}
+
+// Stats return the statistics of every server, keyed by server address.
+// args selects a group such as "slabs", "items", "settings" or "conns", the general statistics without args.
+func (a *admin) Stats(args ...string) (map[string]map[string]string, error) {
+	return a.StatsContext(context.Background(), args...)
+}
+
+// StatsContext is like Stats, ctx bounds the request
+func (a *admin) StatsContext(ctx context.Context, args ...string) (map[string]map[string]string, error) {
+	var mu sync.Mutex
+	stats := make(map[string]map[string]string)
+
+	err := a.each(func(i int) error {
+		s, err := a.commands.Stats(ctx, i, args...)
+		if err == nil {
+			mu.Lock()
+			stats[a.config.Addr(i)] = s
+			mu.Unlock()
+		}
+		return err
+	})
+
+	return stats, err
+}
+
+// StatsServer return the statistics of server, args as for Stats
+func (a *admin) StatsServer(server string, args ...string) (map[string]string, error) {
+	return a.StatsServerContext(context.Background(), server, args...)
+}
+
+// StatsServerContext is like StatsServer, ctx bounds the request
+func (a *admin) StatsServerContext(ctx context.Context, server string, args ...string) (map[string]string, error) {
+	i, err := a.serverIndex(server)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.commands.Stats(ctx, i, args...)
+}
+
+// each call fn for every server concurrently.
+// It returns the error of the first failed server in config.Servers order, prefixed with its address.
+func (a *admin) each(fn func(i int) error) error {
+	errs := make([]error, len(a.config.Servers))
+
+	var wg sync.WaitGroup
+	for i := range a.config.Servers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("Memcached : %s : %w", a.config.Addr(i), err)
+		}
+	}
+
+	return nil
+}
+
+func (a *admin) serverIndex(server string) (int, error) {
+	for i := range a.config.Servers {
+		if a.config.Addr(i) == server {
+			return i, nil
+		}
+	}
+
+	return -1, common.ErrUnknownServer
+}
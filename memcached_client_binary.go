@@ -1,7 +1,7 @@
 package memcached
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/ningjh/memcached/common"
 	"github.com/ningjh/memcached/config"
@@ -11,13 +11,15 @@ import (
 
 // MemcachedClient4B implements the binary protocol.
 type MemcachedClient4B struct {
+	*admin
 	parse *parse.BinaryPorotolParse
+	pool  pool.Pool
 }
 
 // NewMemcachedClient4B return a client that implements the binary protocol.
 func NewMemcachedClient4B(c *config.Config) (*MemcachedClient4B, error) {
 	if len(c.Servers) == 0 {
-		return nil, fmt.Errorf("Memcached : Servers must not empty")
+		return nil, common.ErrNoServers
 	}
 
 	if c.InitConns <= 0 {
@@ -41,89 +43,272 @@ func NewMemcachedClient4B(c *config.Config) (*MemcachedClient4B, error) {
 
 	tpp := parse.NewBinaryProtocolParse(p, c)
 
-	return &MemcachedClient4B{tpp}, nil
+	return &MemcachedClient4B{admin: &admin{tpp, c}, parse: tpp, pool: p}, nil
 }
 
-// store ask the server to store some data identified by a key
-func (client *MemcachedClient4B) store(opr uint8, e *common.Element) error {
+// store ask the server to store some data identified by a key, a zero cas stores it unconditionally
+func (client *MemcachedClient4B) store(ctx context.Context, opr uint8, e *common.Element, cas uint64) error {
 	if e == nil {
-		return fmt.Errorf("Memcached : nil pointer error")
+		return common.ErrNilElement
 	}
 
-	return client.parse.Store(opr, e.Key, e.Flags, e.Exptime, e.Cas, e.Value)
+	return client.parse.Store(ctx, opr, e.Key, e.Flags, e.Exptime, cas, e.Value)
 }
 
 // Set store this data
 func (client *MemcachedClient4B) Set(e *common.Element) error {
-	return client.store(parse.Set, e)
+	return client.SetContext(context.Background(), e)
+}
+
+// SetContext is like Set, ctx bounds the request
+func (client *MemcachedClient4B) SetContext(ctx context.Context, e *common.Element) error {
+	return client.store(ctx, parse.Set, e, 0)
 }
 
 //Add store this data, but only if the server doesn't already hold data for this key
 func (client *MemcachedClient4B) Add(e *common.Element) error {
-	return client.store(parse.Add, e)
+	return client.AddContext(context.Background(), e)
+}
+
+// AddContext is like Add, ctx bounds the request
+func (client *MemcachedClient4B) AddContext(ctx context.Context, e *common.Element) error {
+	return client.store(ctx, parse.Add, e, 0)
 }
 
 // Replace store this data, but only if the server does already hold data for this key
 func (client *MemcachedClient4B) Replace(e *common.Element) error {
-	return client.store(parse.Replace, e)
+	return client.ReplaceContext(context.Background(), e)
+}
+
+// ReplaceContext is like Replace, ctx bounds the request
+func (client *MemcachedClient4B) ReplaceContext(ctx context.Context, e *common.Element) error {
+	return client.store(ctx, parse.Replace, e, 0)
 }
 
 // Append add this data to an existing key after existing data
 func (client *MemcachedClient4B) Append(e *common.Element) error {
+	return client.AppendContext(context.Background(), e)
+}
+
+// AppendContext is like Append, ctx bounds the request
+func (client *MemcachedClient4B) AppendContext(ctx context.Context, e *common.Element) error {
 	if e == nil {
-		return fmt.Errorf("Memcached : nil pointer error")
+		return common.ErrNilElement
 	}
-	return client.parse.AppendOrPrepend(parse.Append, e.Key, e.Value)
+	return client.parse.AppendOrPrepend(ctx, parse.Append, e.Key, e.Value)
 }
 
 // Prepend add this data to an existing key before existing data
 func (client *MemcachedClient4B) Prepend(e *common.Element) error {
+	return client.PrependContext(context.Background(), e)
+}
+
+// PrependContext is like Prepend, ctx bounds the request
+func (client *MemcachedClient4B) PrependContext(ctx context.Context, e *common.Element) error {
 	if e == nil {
-		return fmt.Errorf("Memcached : nil pointer error")
+		return common.ErrNilElement
 	}
-	return client.parse.AppendOrPrepend(parse.Prepend, e.Key, e.Value)
+	return client.parse.AppendOrPrepend(ctx, parse.Prepend, e.Key, e.Value)
 }
 
 // Get retrieval data with this key
-func (client *MemcachedClient4B) Get(key string) (item common.Item, err error) {
-	items := client.parse.Retrieval([]string{key})
+func (client *MemcachedClient4B) Get(key string) (common.Item, error) {
+	return client.GetContext(context.Background(), key)
+}
+
+// GetContext is like Get, ctx bounds the request
+func (client *MemcachedClient4B) GetContext(ctx context.Context, key string) (item common.Item, err error) {
+	items, err := client.parse.Retrieval(ctx, []string{key})
 
-	var ok bool
-	if item, ok = items[key]; !ok {
-		err = fmt.Errorf("Memcached : no data error")
+	if err == nil {
+		var ok bool
+		if item, ok = items[key]; !ok {
+			err = common.ErrCacheMiss
+		}
 	}
 
 	return
 }
 
 // GetArray retrieval datas with keys
-func (client *MemcachedClient4B) GetArray(keys []string) (items map[string]common.Item, err error) {
-	items = client.parse.Retrieval(keys)
+func (client *MemcachedClient4B) GetArray(keys []string) (map[string]common.Item, error) {
+	return client.GetArrayContext(context.Background(), keys)
+}
+
+// GetArrayContext is like GetArray, ctx bounds the request
+func (client *MemcachedClient4B) GetArrayContext(ctx context.Context, keys []string) (items map[string]common.Item, err error) {
+	items, err = client.parse.Retrieval(ctx, keys)
 
-	if len(items) == 0 {
-		err = fmt.Errorf("Memcached : no data error")
+	if err == nil {
+		if len(items) == 0 {
+			err = common.ErrCacheMiss
+			items = nil
+		}
+	} else {
 		items = nil
 	}
 
 	return
 }
 
+// Cas store this data but only if no one else has updated since I last fetched it.
+// e.Cas must come from Gets or GetsArray, it returns ErrCASConflict if the item has been
+// modified since, and ErrCacheMiss if the item no longer exists.
+func (client *MemcachedClient4B) Cas(e *common.Element) error {
+	return client.CasContext(context.Background(), e)
+}
+
+// CasContext is like Cas, ctx bounds the request
+func (client *MemcachedClient4B) CasContext(ctx context.Context, e *common.Element) error {
+	if e == nil {
+		return common.ErrNilElement
+	}
+
+	// a binary set with a zero cas is unconditional, the text protocol answers NOT_FOUND or EXISTS instead
+	if e.Cas == 0 {
+		if _, err := client.GetContext(ctx, e.Key); err != nil {
+			return err
+		}
+
+		return common.ErrCASConflict
+	}
+
+	return client.store(ctx, parse.Set, e, e.Cas)
+}
+
+// Gets retrieval data with this key, include the 'cas' field
+func (client *MemcachedClient4B) Gets(key string) (common.Item, error) {
+	return client.GetsContext(context.Background(), key)
+}
+
+// GetsContext is like Gets, ctx bounds the request
+func (client *MemcachedClient4B) GetsContext(ctx context.Context, key string) (common.Item, error) {
+	return client.GetContext(ctx, key)
+}
+
+// GetsArray retrieval datas with keys, include the 'cas' field
+func (client *MemcachedClient4B) GetsArray(keys []string) (map[string]common.Item, error) {
+	return client.GetsArrayContext(context.Background(), keys)
+}
+
+// GetsArrayContext is like GetsArray, ctx bounds the request
+func (client *MemcachedClient4B) GetsArrayContext(ctx context.Context, keys []string) (map[string]common.Item, error) {
+	return client.GetArrayContext(ctx, keys)
+}
+
 // Delete delete data with this key
 func (client *MemcachedClient4B) Delete(key string) error {
-	return client.parse.Deletion(key)
+	return client.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext is like Delete, ctx bounds the request
+func (client *MemcachedClient4B) DeleteContext(ctx context.Context, key string) error {
+	return client.parse.Deletion(ctx, key)
 }
 
 // Incr change data for some item in-place, incrementing it
 func (client *MemcachedClient4B) Incr(key string, value uint64) (uint64, error) {
-	return client.parse.IncrOrDecr(parse.Increment, key, value, 0xffffffff)
+	return client.IncrContext(context.Background(), key, value)
+}
+
+// IncrContext is like Incr, ctx bounds the request
+func (client *MemcachedClient4B) IncrContext(ctx context.Context, key string, value uint64) (uint64, error) {
+	return client.parse.IncrOrDecr(ctx, parse.Increment, key, value, 0xffffffff)
 }
 
 // Decr change data for some item in-place, decrementing it
 func (client *MemcachedClient4B) Decr(key string, value uint64) (uint64, error) {
-	return client.parse.IncrOrDecr(parse.Decrement, key, value, 0xffffffff)
+	return client.DecrContext(context.Background(), key, value)
+}
+
+// DecrContext is like Decr, ctx bounds the request
+func (client *MemcachedClient4B) DecrContext(ctx context.Context, key string, value uint64) (uint64, error) {
+	return client.parse.IncrOrDecr(ctx, parse.Decrement, key, value, 0xffffffff)
 }
 
 // Touch update the expiration time of an existing item without fetching it
 func (client *MemcachedClient4B) Touch(key string, exptime uint32) error {
-	return client.parse.Touch(key, exptime)
+	return client.TouchContext(context.Background(), key, exptime)
+}
+
+// TouchContext is like Touch, ctx bounds the request
+func (client *MemcachedClient4B) TouchContext(ctx context.Context, key string, exptime uint32) error {
+	return client.parse.Touch(ctx, key, exptime)
+}
+
+// GetAndTouch retrieval data with this key and update its expiration time, include the 'cas' field
+func (client *MemcachedClient4B) GetAndTouch(key string, exptime uint32) (common.Item, error) {
+	return client.GetAndTouchContext(context.Background(), key, exptime)
+}
+
+// GetAndTouchContext is like GetAndTouch, ctx bounds the request
+func (client *MemcachedClient4B) GetAndTouchContext(ctx context.Context, key string, exptime uint32) (item common.Item, err error) {
+	items, err := client.parse.RetrievalAndTouch(ctx, exptime, []string{key})
+
+	if err == nil {
+		var ok bool
+		if item, ok = items[key]; !ok {
+			err = common.ErrCacheMiss
+		}
+	}
+
+	return
+}
+
+// GetAndTouchArray retrieval datas with keys and update their expiration time, include the 'cas' field
+func (client *MemcachedClient4B) GetAndTouchArray(keys []string, exptime uint32) (map[string]common.Item, error) {
+	return client.GetAndTouchArrayContext(context.Background(), keys, exptime)
+}
+
+// GetAndTouchArrayContext is like GetAndTouchArray, ctx bounds the request
+func (client *MemcachedClient4B) GetAndTouchArrayContext(ctx context.Context, keys []string, exptime uint32) (items map[string]common.Item, err error) {
+	items, err = client.parse.RetrievalAndTouch(ctx, exptime, keys)
+
+	if err == nil {
+		if len(items) == 0 {
+			err = common.ErrCacheMiss
+			items = nil
+		}
+	} else {
+		items = nil
+	}
+
+	return
+}
+
+// GetsAndTouch retrieval data with this key and update its expiration time, include the 'cas' field
+func (client *MemcachedClient4B) GetsAndTouch(key string, exptime uint32) (common.Item, error) {
+	return client.GetsAndTouchContext(context.Background(), key, exptime)
+}
+
+// GetsAndTouchContext is like GetsAndTouch, ctx bounds the request
+func (client *MemcachedClient4B) GetsAndTouchContext(ctx context.Context, key string, exptime uint32) (common.Item, error) {
+	return client.GetAndTouchContext(ctx, key, exptime)
+}
+
+// GetsAndTouchArray retrieval datas with keys and update their expiration time, include the 'cas' field
+func (client *MemcachedClient4B) GetsAndTouchArray(keys []string, exptime uint32) (map[string]common.Item, error) {
+	return client.GetsAndTouchArrayContext(context.Background(), keys, exptime)
+}
+
+// GetsAndTouchArrayContext is like GetsAndTouchArray, ctx bounds the request
+func (client *MemcachedClient4B) GetsAndTouchArrayContext(ctx context.Context, keys []string, exptime uint32) (map[string]common.Item, error) {
+	return client.GetAndTouchArrayContext(ctx, keys, exptime)
+}
+
+// Close stop the background goroutines and close every pooled connection.
+// Later calls return ErrClientClosed.
+func (client *MemcachedClient4B) Close() error {
+	return client.pool.Close()
+}
+
+// Shutdown stop handing out connections, wait for the requests in flight to finish and close the client.
+// If ctx is done first, the requests in flight are interrupted and ctx.Err() is returned.
+func (client *MemcachedClient4B) Shutdown(ctx context.Context) error {
+	return client.pool.Shutdown(ctx)
+}
+
+// PoolStats return a snapshot of the connection pool statistics, keyed by server address.
+func (client *MemcachedClient4B) PoolStats() map[string]PoolStats {
+	return client.pool.Stats()
 }
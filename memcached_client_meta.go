@@ -0,0 +1,101 @@
+package memcached
+
+import (
+	"context"
+
+	"github.com/ningjh/memcached/common"
+)
+
+// MetaClient the meta commands of memcached 1.6 and later, implemented by MemcachedClient4T.
+// The status of the response is also returned as an error: EN and NF as ErrCacheMiss,
+// NS as ErrNotStored and EX as ErrCASConflict, the result holds the returned flags in every case.
+type MetaClient interface {
+	Client
+
+	MetaGet(key string, flags *common.MetaFlags) (*common.MetaResult, error)
+	MetaSet(key string, value []byte, flags *common.MetaFlags) (*common.MetaResult, error)
+	MetaDelete(key string, flags *common.MetaFlags) (*common.MetaResult, error)
+	MetaArithmetic(key string, flags *common.MetaFlags) (*common.MetaResult, error)
+	MetaNoop() error
+	MetaNoopServer(server string) error
+	GetOrRecache(key string, ttl uint32, loader Loader) ([]byte, error)
+	Invalidate(key string) error
+
+	MetaGetContext(ctx context.Context, key string, flags *common.MetaFlags) (*common.MetaResult, error)
+	MetaSetContext(ctx context.Context, key string, value []byte, flags *common.MetaFlags) (*common.MetaResult, error)
+	MetaDeleteContext(ctx context.Context, key string, flags *common.MetaFlags) (*common.MetaResult, error)
+	MetaArithmeticContext(ctx context.Context, key string, flags *common.MetaFlags) (*common.MetaResult, error)
+	MetaNoopContext(ctx context.Context) error
+	MetaNoopServerContext(ctx context.Context, server string) error
+	GetOrRecacheContext(ctx context.Context, key string, ttl uint32, loader Loader) ([]byte, error)
+	InvalidateContext(ctx context.Context, key string) error
+}
+
+var _ MetaClient = (*MemcachedClient4T)(nil)
+
+// MetaGet get the item with key (mg), flags select what is returned
+func (client *MemcachedClient4T) MetaGet(key string, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return client.MetaGetContext(context.Background(), key, flags)
+}
+
+// MetaGetContext is like MetaGet, ctx bounds the request
+func (client *MemcachedClient4T) MetaGetContext(ctx context.Context, key string, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return client.parse.MetaGet(ctx, key, flags)
+}
+
+// MetaSet store value with key (ms), flags select the mode and what is returned
+func (client *MemcachedClient4T) MetaSet(key string, value []byte, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return client.MetaSetContext(context.Background(), key, value, flags)
+}
+
+// MetaSetContext is like MetaSet, ctx bounds the request
+func (client *MemcachedClient4T) MetaSetContext(ctx context.Context, key string, value []byte, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return client.parse.MetaSet(ctx, key, value, flags)
+}
+
+// MetaDelete delete the item with key (md), with the I flag the item is marked stale instead
+func (client *MemcachedClient4T) MetaDelete(key string, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return client.MetaDeleteContext(context.Background(), key, flags)
+}
+
+// MetaDeleteContext is like MetaDelete, ctx bounds the request
+func (client *MemcachedClient4T) MetaDeleteContext(ctx context.Context, key string, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return client.parse.MetaDelete(ctx, key, flags)
+}
+
+// MetaArithmetic increment or decrement the item with key (ma)
+func (client *MemcachedClient4T) MetaArithmetic(key string, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return client.MetaArithmeticContext(context.Background(), key, flags)
+}
+
+// MetaArithmeticContext is like MetaArithmetic, ctx bounds the request
+func (client *MemcachedClient4T) MetaArithmeticContext(ctx context.Context, key string, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return client.parse.MetaArithmetic(ctx, key, flags)
+}
+
+// MetaNoop send a meta no-op to every server (mn)
+func (client *MemcachedClient4T) MetaNoop() error {
+	return client.MetaNoopContext(context.Background())
+}
+
+// MetaNoopContext is like MetaNoop, ctx bounds the request
+func (client *MemcachedClient4T) MetaNoopContext(ctx context.Context) error {
+	return client.each(func(i int) error {
+		return client.parse.MetaNoop(ctx, i)
+	})
+}
+
+// MetaNoopServer send a meta no-op to server (mn)
+func (client *MemcachedClient4T) MetaNoopServer(server string) error {
+	return client.MetaNoopServerContext(context.Background(), server)
+}
+
+// MetaNoopServerContext is like MetaNoopServer, ctx bounds the request
+func (client *MemcachedClient4T) MetaNoopServerContext(ctx context.Context, server string) error {
+	i, err := client.serverIndex(server)
+	if err != nil {
+		return err
+	}
+
+	return client.parse.MetaNoop(ctx, i)
+}
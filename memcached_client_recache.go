@@ -0,0 +1,87 @@
+package memcached
+
+import (
+	"context"
+	"time"
+
+	"github.com/ningjh/memcached/common"
+)
+
+// Loader compute the value of key for GetOrRecache
+type Loader func(ctx context.Context, key string) ([]byte, error)
+
+const (
+	// seconds an empty placeholder created on miss lives, so a failed winner does not block the key forever
+	recacheLockTTL = 30
+	// how long a loser without a stale value waits before asking again
+	recacheWait = 10 * time.Millisecond
+)
+
+// GetOrRecache get the value of key, on miss or when the item is stale exactly one caller
+// wins the right to call loader and store its value for ttl seconds, the others get the stale value.
+// An item is also recached early by one caller when less than a tenth of ttl remains.
+// Callers without a stale value to return wait for the winner until ctx is done, they never call loader themselves,
+// a winner that neither stores nor gives up blocks them until its placeholder expires after recacheLockTTL seconds.
+func (client *MemcachedClient4T) GetOrRecache(key string, ttl uint32, loader Loader) ([]byte, error) {
+	return client.GetOrRecacheContext(context.Background(), key, ttl, loader)
+}
+
+// GetOrRecacheContext is like GetOrRecache, ctx bounds the requests and is passed to loader
+func (client *MemcachedClient4T) GetOrRecacheContext(ctx context.Context, key string, ttl uint32, loader Loader) ([]byte, error) {
+	flags := common.NewMetaFlags().Value().ReturnCas().Vivify(recacheLockTTL).Recache(ttl/10 + 1)
+
+	for {
+		result, err := client.parse.MetaGet(ctx, key, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Win {
+			return client.recache(ctx, key, ttl, result, loader)
+		}
+
+		// an empty placeholder, the winner is still loading
+		if result.AlreadyWon && !result.Stale && len(result.Value) == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(recacheWait):
+			}
+			continue
+		}
+
+		return result.Value, nil
+	}
+}
+
+// recache call loader and store its value, result is the won response of mg
+func (client *MemcachedClient4T) recache(ctx context.Context, key string, ttl uint32, result *common.MetaResult, loader Loader) ([]byte, error) {
+	value, err := loader(ctx, key)
+	if err != nil {
+		// give up the placeholder so the next caller can win, a stale item is kept
+		if !result.Stale && len(result.Value) == 0 {
+			client.parse.MetaDelete(ctx, key, common.NewMetaFlags().CompareCas(result.Cas).NoReply())
+		}
+		return nil, err
+	}
+
+	// only the item we won is replaced, a newer value stored meanwhile is kept
+	_, err = client.parse.MetaSet(ctx, key, value, common.NewMetaFlags().TTL(ttl).CompareCas(result.Cas))
+	if err == common.ErrCASConflict || err == common.ErrCacheMiss {
+		err = nil
+	}
+
+	return value, err
+}
+
+// Invalidate mark the item with key stale instead of deleting it,
+// the next GetOrRecache recaches it while the others still get the stale value
+func (client *MemcachedClient4T) Invalidate(key string) error {
+	return client.InvalidateContext(context.Background(), key)
+}
+
+// InvalidateContext is like Invalidate, ctx bounds the request
+func (client *MemcachedClient4T) InvalidateContext(ctx context.Context, key string) error {
+	_, err := client.parse.MetaDelete(ctx, key, common.NewMetaFlags().Invalidate())
+	return err
+}
@@ -2,7 +2,7 @@
 package memcached
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/ningjh/memcached/common"
 	"github.com/ningjh/memcached/config"
@@ -12,13 +12,15 @@ import (
 
 // MemcachedClient4T implements the text protocol.
 type MemcachedClient4T struct {
+	*admin
 	parse *parse.TextProtocolParse
+	pool  pool.Pool
 }
 
 // NewMemcachedClient4T return a client that implements the text protocol.
 func NewMemcachedClient4T(c *config.Config) (*MemcachedClient4T, error) {
 	if len(c.Servers) == 0 {
-		return nil, fmt.Errorf("Memcached : Servers must not empty")
+		return nil, common.ErrNoServers
 	}
 
 	if c.InitConns <= 0 {
@@ -42,56 +44,93 @@ func NewMemcachedClient4T(c *config.Config) (*MemcachedClient4T, error) {
 
 	tpp := parse.NewTextProtocolParse(p, c)
 
-	return &MemcachedClient4T{tpp}, nil
+	return &MemcachedClient4T{admin: &admin{tpp, c}, parse: tpp, pool: p}, nil
 }
 
 // store ask the server to store some data identified by a key
-func (client *MemcachedClient4T) store(opr string, e *common.Element) error {
+func (client *MemcachedClient4T) store(ctx context.Context, opr string, e *common.Element) error {
 	if e == nil {
-		return fmt.Errorf("Memcached : nil pointer error")
+		return common.ErrNilElement
 	}
 
-	return client.parse.Store(opr, e.Key, e.Flags, e.Exptime, e.Cas, e.Value)
+	return client.parse.Store(ctx, opr, e.Key, e.Flags, e.Exptime, e.Cas, e.Value)
 }
 
 // Set store this data
 func (client *MemcachedClient4T) Set(e *common.Element) error {
-	return client.store("set", e)
+	return client.SetContext(context.Background(), e)
+}
+
+// SetContext is like Set, ctx bounds the request
+func (client *MemcachedClient4T) SetContext(ctx context.Context, e *common.Element) error {
+	return client.store(ctx, "set", e)
 }
 
 //Add store this data, but only if the server doesn't already hold data for this key
 func (client *MemcachedClient4T) Add(e *common.Element) error {
-	return client.store("add", e)
+	return client.AddContext(context.Background(), e)
+}
+
+// AddContext is like Add, ctx bounds the request
+func (client *MemcachedClient4T) AddContext(ctx context.Context, e *common.Element) error {
+	return client.store(ctx, "add", e)
 }
 
 // Replace store this data, but only if the server does already hold data for this key
 func (client *MemcachedClient4T) Replace(e *common.Element) error {
-	return client.store("replace", e)
+	return client.ReplaceContext(context.Background(), e)
+}
+
+// ReplaceContext is like Replace, ctx bounds the request
+func (client *MemcachedClient4T) ReplaceContext(ctx context.Context, e *common.Element) error {
+	return client.store(ctx, "replace", e)
 }
 
 // Append add this data to an existing key after existing data
 func (client *MemcachedClient4T) Append(e *common.Element) error {
-	return client.store("append", e)
+	return client.AppendContext(context.Background(), e)
+}
+
+// AppendContext is like Append, ctx bounds the request
+func (client *MemcachedClient4T) AppendContext(ctx context.Context, e *common.Element) error {
+	return client.store(ctx, "append", e)
 }
 
 // Prepend add this data to an existing key before existing data
 func (client *MemcachedClient4T) Prepend(e *common.Element) error {
-	return client.store("prepend", e)
+	return client.PrependContext(context.Background(), e)
 }
 
-// Cas store this data but only if no one else has updated since I last fetched it
+// PrependContext is like Prepend, ctx bounds the request
+func (client *MemcachedClient4T) PrependContext(ctx context.Context, e *common.Element) error {
+	return client.store(ctx, "prepend", e)
+}
+
+// Cas store this data but only if no one else has updated since I last fetched it.
+// e.Cas must come from Gets or GetsArray, it returns ErrCASConflict if the item has been
+// modified since, and ErrCacheMiss if the item no longer exists.
 func (client *MemcachedClient4T) Cas(e *common.Element) error {
-	return client.store("cas", e)
+	return client.CasContext(context.Background(), e)
+}
+
+// CasContext is like Cas, ctx bounds the request
+func (client *MemcachedClient4T) CasContext(ctx context.Context, e *common.Element) error {
+	return client.store(ctx, "cas", e)
 }
 
 // Get retrieval data with this key
-func (client *MemcachedClient4T) Get(key string) (item common.Item, err error) {
-	items, err := client.parse.Retrieval("get", []string{key})
+func (client *MemcachedClient4T) Get(key string) (common.Item, error) {
+	return client.GetContext(context.Background(), key)
+}
+
+// GetContext is like Get, ctx bounds the request
+func (client *MemcachedClient4T) GetContext(ctx context.Context, key string) (item common.Item, err error) {
+	items, err := client.parse.Retrieval(ctx, "get", []string{key})
 
 	if err == nil {
 		var ok bool
 		if item, ok = items[key]; !ok {
-			err = fmt.Errorf("Memcached : no data error")
+			err = common.ErrCacheMiss
 		}
 	}
 
@@ -99,12 +138,17 @@ func (client *MemcachedClient4T) Get(key string) (item common.Item, err error) {
 }
 
 // GetArray retrieval datas with keys
-func (client *MemcachedClient4T) GetArray(keys []string) (items map[string]common.Item, err error) {
-	items, err = client.parse.Retrieval("get", keys)
+func (client *MemcachedClient4T) GetArray(keys []string) (map[string]common.Item, error) {
+	return client.GetArrayContext(context.Background(), keys)
+}
+
+// GetArrayContext is like GetArray, ctx bounds the request
+func (client *MemcachedClient4T) GetArrayContext(ctx context.Context, keys []string) (items map[string]common.Item, err error) {
+	items, err = client.parse.Retrieval(ctx, "get", keys)
 
 	if err == nil {
 		if len(items) == 0 {
-			err = fmt.Errorf("Memcached : no data error")
+			err = common.ErrCacheMiss
 			items = nil
 		}
 	} else {
@@ -115,13 +159,18 @@ func (client *MemcachedClient4T) GetArray(keys []string) (items map[string]commo
 }
 
 // Gets retrieval data with this key, include the 'cas' field
-func (client *MemcachedClient4T) Gets(key string) (item common.Item, err error) {
-	items, err := client.parse.Retrieval("gets", []string{key})
+func (client *MemcachedClient4T) Gets(key string) (common.Item, error) {
+	return client.GetsContext(context.Background(), key)
+}
+
+// GetsContext is like Gets, ctx bounds the request
+func (client *MemcachedClient4T) GetsContext(ctx context.Context, key string) (item common.Item, err error) {
+	items, err := client.parse.Retrieval(ctx, "gets", []string{key})
 
 	if err == nil {
 		var ok bool
 		if item, ok = items[key]; !ok {
-			err = fmt.Errorf("Memcached : no data error")
+			err = common.ErrCacheMiss
 		}
 	}
 
@@ -129,12 +178,17 @@ func (client *MemcachedClient4T) Gets(key string) (item common.Item, err error)
 }
 
 // GetsArray retrieval datas with keys, include the 'cas' field
-func (client *MemcachedClient4T) GetsArray(keys []string) (items map[string]common.Item, err error) {
-	items, err = client.parse.Retrieval("gets", keys)
+func (client *MemcachedClient4T) GetsArray(keys []string) (map[string]common.Item, error) {
+	return client.GetsArrayContext(context.Background(), keys)
+}
+
+// GetsArrayContext is like GetsArray, ctx bounds the request
+func (client *MemcachedClient4T) GetsArrayContext(ctx context.Context, keys []string) (items map[string]common.Item, err error) {
+	items, err = client.parse.Retrieval(ctx, "gets", keys)
 
 	if err == nil {
 		if len(items) == 0 {
-			err = fmt.Errorf("Memcached : no data error")
+			err = common.ErrCacheMiss
 			items = nil
 		}
 	} else {
@@ -146,20 +200,137 @@ func (client *MemcachedClient4T) GetsArray(keys []string) (items map[string]comm
 
 // Delete delete data with this key
 func (client *MemcachedClient4T) Delete(key string) error {
-	return client.parse.Deletion(key)
+	return client.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext is like Delete, ctx bounds the request
+func (client *MemcachedClient4T) DeleteContext(ctx context.Context, key string) error {
+	return client.parse.Deletion(ctx, key)
 }
 
 // Incr change data for some item in-place, incrementing it
 func (client *MemcachedClient4T) Incr(key string, value uint64) (uint64, error) {
-	return client.parse.IncrOrDecr("incr", key, value)
+	return client.IncrContext(context.Background(), key, value)
+}
+
+// IncrContext is like Incr, ctx bounds the request
+func (client *MemcachedClient4T) IncrContext(ctx context.Context, key string, value uint64) (uint64, error) {
+	return client.parse.IncrOrDecr(ctx, "incr", key, value)
 }
 
 // Decr change data for some item in-place, decrementing it
 func (client *MemcachedClient4T) Decr(key string, value uint64) (uint64, error) {
-	return client.parse.IncrOrDecr("decr", key, value)
+	return client.DecrContext(context.Background(), key, value)
+}
+
+// DecrContext is like Decr, ctx bounds the request
+func (client *MemcachedClient4T) DecrContext(ctx context.Context, key string, value uint64) (uint64, error) {
+	return client.parse.IncrOrDecr(ctx, "decr", key, value)
 }
 
 // Touch update the expiration time of an existing item without fetching it
 func (client *MemcachedClient4T) Touch(key string, exptime uint32) error {
-	return client.parse.Touch(key, exptime)
+	return client.TouchContext(context.Background(), key, exptime)
+}
+
+// TouchContext is like Touch, ctx bounds the request
+func (client *MemcachedClient4T) TouchContext(ctx context.Context, key string, exptime uint32) error {
+	return client.parse.Touch(ctx, key, exptime)
+}
+
+// GetAndTouch retrieval data with this key and update its expiration time
+func (client *MemcachedClient4T) GetAndTouch(key string, exptime uint32) (common.Item, error) {
+	return client.GetAndTouchContext(context.Background(), key, exptime)
+}
+
+// GetAndTouchContext is like GetAndTouch, ctx bounds the request
+func (client *MemcachedClient4T) GetAndTouchContext(ctx context.Context, key string, exptime uint32) (item common.Item, err error) {
+	items, err := client.parse.RetrievalAndTouch(ctx, "gat", exptime, []string{key})
+
+	if err == nil {
+		var ok bool
+		if item, ok = items[key]; !ok {
+			err = common.ErrCacheMiss
+		}
+	}
+
+	return
+}
+
+// GetAndTouchArray retrieval datas with keys and update their expiration time
+func (client *MemcachedClient4T) GetAndTouchArray(keys []string, exptime uint32) (map[string]common.Item, error) {
+	return client.GetAndTouchArrayContext(context.Background(), keys, exptime)
+}
+
+// GetAndTouchArrayContext is like GetAndTouchArray, ctx bounds the request
+func (client *MemcachedClient4T) GetAndTouchArrayContext(ctx context.Context, keys []string, exptime uint32) (items map[string]common.Item, err error) {
+	items, err = client.parse.RetrievalAndTouch(ctx, "gat", exptime, keys)
+
+	if err == nil {
+		if len(items) == 0 {
+			err = common.ErrCacheMiss
+			items = nil
+		}
+	} else {
+		items = nil
+	}
+
+	return
+}
+
+// GetsAndTouch retrieval data with this key and update its expiration time, include the 'cas' field
+func (client *MemcachedClient4T) GetsAndTouch(key string, exptime uint32) (common.Item, error) {
+	return client.GetsAndTouchContext(context.Background(), key, exptime)
+}
+
+// GetsAndTouchContext is like GetsAndTouch, ctx bounds the request
+func (client *MemcachedClient4T) GetsAndTouchContext(ctx context.Context, key string, exptime uint32) (item common.Item, err error) {
+	items, err := client.parse.RetrievalAndTouch(ctx, "gats", exptime, []string{key})
+
+	if err == nil {
+		var ok bool
+		if item, ok = items[key]; !ok {
+			err = common.ErrCacheMiss
+		}
+	}
+
+	return
+}
+
+// GetsAndTouchArray retrieval datas with keys and update their expiration time, include the 'cas' field
+func (client *MemcachedClient4T) GetsAndTouchArray(keys []string, exptime uint32) (map[string]common.Item, error) {
+	return client.GetsAndTouchArrayContext(context.Background(), keys, exptime)
+}
+
+// GetsAndTouchArrayContext is like GetsAndTouchArray, ctx bounds the request
+func (client *MemcachedClient4T) GetsAndTouchArrayContext(ctx context.Context, keys []string, exptime uint32) (items map[string]common.Item, err error) {
+	items, err = client.parse.RetrievalAndTouch(ctx, "gats", exptime, keys)
+
+	if err == nil {
+		if len(items) == 0 {
+			err = common.ErrCacheMiss
+			items = nil
+		}
+	} else {
+		items = nil
+	}
+
+	return
+}
+
+// Close stop the background goroutines and close every pooled connection.
+// Later calls return ErrClientClosed.
+func (client *MemcachedClient4T) Close() error {
+	return client.pool.Close()
+}
+
+// Shutdown stop handing out connections, wait for the requests in flight to finish and close the client.
+// If ctx is done first, the requests in flight are interrupted and ctx.Err() is returned.
+func (client *MemcachedClient4T) Shutdown(ctx context.Context) error {
+	return client.pool.Shutdown(ctx)
+}
+
+// PoolStats return a snapshot of the connection pool statistics, keyed by server address.
+func (client *MemcachedClient4T) PoolStats() map[string]PoolStats {
+	return client.pool.Stats()
 }
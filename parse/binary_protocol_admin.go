@@ -0,0 +1,140 @@
+package parse
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+
+	"github.com/ningjh/memcached/common"
+)
+
+// FlushAll invalidate all existing items of the i-th server, after delay seconds
+func (parse *BinaryPorotolParse) FlushAll(ctx context.Context, i int, delay uint32) (err error) {
+	conn, err := parse.acquireServer(ctx, i)
+	if err != nil {
+		return
+	}
+
+	reqPacket := &packet{
+		magic:  reqMagic,
+		opcode: Flush,
+	}
+
+	if delay > 0 {
+		reqPacket.extrasLength = 4
+		reqPacket.totalBodyLength = 4
+		reqPacket.extras = make([]byte, reqPacket.extrasLength)
+		binary.BigEndian.PutUint32(reqPacket.extras, delay)
+	}
+
+	_, err = parse.requestAndResponse(conn, reqPacket)
+
+	return
+}
+
+// Version return the version string of the i-th server
+func (parse *BinaryPorotolParse) Version(ctx context.Context, i int) (v string, err error) {
+	conn, err := parse.acquireServer(ctx, i)
+	if err != nil {
+		return
+	}
+
+	reqPacket := &packet{
+		magic:  reqMagic,
+		opcode: Version,
+	}
+
+	resPacket, err := parse.requestAndResponse(conn, reqPacket)
+	if err == nil {
+		v = string(resPacket.value)
+	}
+
+	return
+}
+
+// Verbosity set the verbosity level of the logging output of the i-th server
+func (parse *BinaryPorotolParse) Verbosity(ctx context.Context, i int, level uint32) (err error) {
+	conn, err := parse.acquireServer(ctx, i)
+	if err != nil {
+		return
+	}
+
+	reqPacket := &packet{
+		magic:           reqMagic,
+		opcode:          Verbosity,
+		extrasLength:    4,
+		totalBodyLength: 4,
+	}
+	reqPacket.extras = make([]byte, reqPacket.extrasLength)
+	binary.BigEndian.PutUint32(reqPacket.extras, level)
+
+	_, err = parse.requestAndResponse(conn, reqPacket)
+
+	return
+}
+
+// Stats return the statistics of the i-th server, args selects a group such as "slabs" or "items"
+func (parse *BinaryPorotolParse) Stats(ctx context.Context, i int, args ...string) (stats map[string]string, err error) {
+	conn, err := parse.acquireServer(ctx, i)
+	if err != nil {
+		return
+	}
+
+	group := strings.Join(args, " ")
+
+	reqPacket := &packet{
+		magic:           reqMagic,
+		opcode:          Stat,
+		keyLength:       uint16(len(group)),
+		totalBodyLength: uint32(len(group)),
+		key:             []byte(group),
+	}
+
+	if err = parse.fillPacket(reqPacket, conn); err != nil {
+		parse.release(conn, true)
+		return
+	}
+
+	if err = conn.Flush(); err != nil {
+		parse.release(conn, true)
+		return
+	}
+
+	stats = make(map[string]string)
+
+	// one packet per statistic, a packet without key terminates the sequence
+	for {
+		resPacket, err := parse.parsePacket(conn)
+		if err != nil {
+			parse.release(conn, true)
+			return nil, err
+		}
+
+		if err = parse.checkError(conn.Server(), resPacket.statusOrVbucket); err != nil {
+			parse.release(conn, false)
+			return nil, err
+		}
+
+		if resPacket.keyLength == 0 {
+			break
+		}
+
+		stats[string(resPacket.key)] = string(resPacket.value)
+	}
+
+	parse.release(conn, false)
+
+	return
+}
+
+// acquireServer get a connect to the i-th server and bind ctx to it
+func (parse *BinaryPorotolParse) acquireServer(ctx context.Context, i int) (*common.Conn, error) {
+	conn, err := parse.pool.GetServer(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.Bind(ctx)
+
+	return conn, nil
+}
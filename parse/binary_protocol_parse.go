@@ -5,8 +5,9 @@ import (
 	"github.com/ningjh/memcached/config"
 	"github.com/ningjh/memcached/pool"
 
-	"errors"
+	"context"
 	"encoding/binary"
+	"fmt"
 )
 
 const (
@@ -30,12 +31,23 @@ const (
 	Delete    uint8 = 0x04
 	Increment uint8 = 0x05
 	Decrement uint8 = 0x06
+	Flush     uint8 = 0x08
 	GetQ      uint8 = 0x09
+	Version   uint8 = 0x0b
 	GetK      uint8 = 0x0c
 	GetKQ     uint8 = 0x0d
 	Append    uint8 = 0x0e
 	Prepend   uint8 = 0x0f
+	Stat      uint8 = 0x10
+	Verbosity uint8 = 0x1b
 	Touch     uint8 = 0x1c
+	GAT       uint8 = 0x1d
+	GATQ      uint8 = 0x1e
+	SASLList  uint8 = 0x20
+	SASLAuth  uint8 = 0x21
+	SASLStep  uint8 = 0x22
+	GATK      uint8 = 0x23
+	GATKQ     uint8 = 0x24
 
 	// magic byte
 	reqMagic uint8 = 0x80
@@ -67,34 +79,57 @@ func NewBinaryProtocolParse(p pool.Pool, c *config.Config) *BinaryPorotolParse {
 	return &BinaryPorotolParse{pool: p, config: c}
 }
 
+// acquire get a connect from the pool and bind ctx to it
+func (parse *BinaryPorotolParse) acquire(ctx context.Context, key string) (*common.Conn, error) {
+	if err := common.CheckKey(key); err != nil {
+		return nil, err
+	}
+
+	conn, err := parse.pool.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.Bind(ctx)
+
+	return conn, nil
+}
+
 func (parse *BinaryPorotolParse) release(conn *common.Conn, doClose bool) {
+	// a cancelled request may leave a half-read response, never put it back to the pool
+	if err := conn.Unbind(); err != nil {
+		doClose = true
+	}
+
 	if doClose {
-		go conn.Close()
+		parse.pool.Discard(conn)
 	} else {
-		go parse.pool.Release(conn)
+		parse.pool.Release(conn)
 	}
 }
 
 // checkError if the status code of a response packet is no zero, return error.
-func (parse *BinaryPorotolParse) checkError(status uint16) (err error) {
+func (parse *BinaryPorotolParse) checkError(server string, status uint16) (err error) {
 	switch status {
 		case 0x0000 : err = nil
-		case 0x0001 : err = errors.New("Memcached : Key not found")
-		case 0x0002 : err = errors.New("Memcached : Key exists")
-		case 0x0003 : err = errors.New("Memcached : Value too large")
-		case 0x0004 : err = errors.New("Memcached : Invalid arguments")
-		case 0x0005 : err = errors.New("Memcached : Item not stored")
-		case 0x0006 : err = errors.New("Memcached : Incr/Decr on non-numeric value")
-		case 0x0007 : err = errors.New("Memcached : The vbucket belongs to another server")
-		case 0x0008 : err = errors.New("Memcached : Authentication error")
-		case 0x0009 : err = errors.New("Memcached : Authentication continue")
-		case 0x0081 : err = errors.New("Memcached : Unknown command")
-		case 0x0082 : err = errors.New("Memcached : Out of memory")
-		case 0x0083 : err = errors.New("Memcached : Not supported")
-		case 0x0084 : err = errors.New("Memcached : Internal error")
-		case 0x0085 : err = errors.New("Memcached : Busy")
-		case 0x0086 : err = errors.New("Memcached : Temporary failure")
-		default     : err = errors.New("Memcached : Unknow error")
+		case 0x0001 : err = common.ErrCacheMiss
+		case 0x0002 : err = common.ErrCASConflict
+		case 0x0003 : err = common.ErrValueTooLarge
+		case 0x0004 : err = &common.ErrClientError{Server: server, Msg: "Invalid arguments"}
+		case 0x0005 : err = common.ErrNotStored
+		case 0x0006 : err = common.ErrNonNumeric
+		case 0x0007 : err = &common.ErrServerError{Server: server, Msg: "The vbucket belongs to another server"}
+		case 0x0008 : err = common.ErrAuthFailed
+		case 0x0009 : err = common.ErrAuthContinue
+		case 0x0020 : err = common.ErrAuthFailed
+		case 0x0021 : err = common.ErrAuthContinue
+		case 0x0081 : err = common.ErrUnknownCommand
+		case 0x0082 : err = &common.ErrServerError{Server: server, Msg: "Out of memory"}
+		case 0x0083 : err = common.ErrNotSupported
+		case 0x0084 : err = &common.ErrServerError{Server: server, Msg: "Internal error"}
+		case 0x0085 : err = &common.ErrServerError{Server: server, Msg: "Busy"}
+		case 0x0086 : err = &common.ErrServerError{Server: server, Msg: "Temporary failure"}
+		default     : err = &common.ErrServerError{Server: server, Msg: fmt.Sprintf("Unknown status 0x%04x", status)}
 	}
 
 	return
@@ -131,10 +166,10 @@ func (parse *BinaryPorotolParse) parsePacket(conn *common.Conn) (p *packet, err
 	var i, n   int
 
 	// read response header
-	if n, err = conn.Read(header); err != nil {
+	if n, err = conn.ReadFull(header); err != nil {
 		return
 	} else if n != headerLen {
-		err = errors.New("Memcached : Unknow error")
+		err = common.ErrMalformedResponse
 		return
 	}
 
@@ -150,14 +185,19 @@ func (parse *BinaryPorotolParse) parsePacket(conn *common.Conn) (p *packet, err
 	p.opaque          = binary.BigEndian.Uint32(header[i:i+opaqueLen]);    i += opaqueLen
 	p.cas             = binary.BigEndian.Uint64(header[i:i+casLen])
 
+	if p.magic != resMagic {
+		err = common.ErrMalformedResponse
+		return
+	}
+
 	// read extras from response if exist
 	if p.extrasLength > 0 {
 		p.extras = make([]byte, p.extrasLength)
 
-		if n, err = conn.Read(p.extras); err != nil {
+		if n, err = conn.ReadFull(p.extras); err != nil {
 			return
 		} else if n != int(p.extrasLength) {
-			err = errors.New("Memcached : Unknow error")
+			err = common.ErrMalformedResponse
 			return
 		}
 	}
@@ -166,10 +206,10 @@ func (parse *BinaryPorotolParse) parsePacket(conn *common.Conn) (p *packet, err
 	if p.keyLength > 0 {
 		p.key = make([]byte, p.keyLength)
 
-		if n, err = conn.Read(p.key); err != nil {
+		if n, err = conn.ReadFull(p.key); err != nil {
 			return
 		} else if n != int(p.keyLength) {
-			err = errors.New("Memcached : Unknow error")
+			err = common.ErrMalformedResponse
 			return
 		}
 	}
@@ -179,10 +219,10 @@ func (parse *BinaryPorotolParse) parsePacket(conn *common.Conn) (p *packet, err
 	if valueLength > 0 {
 		p.value = make([]byte, valueLength)
 
-		if n, err = conn.Read(p.value); err != nil {
+		if n, err = conn.ReadFull(p.value); err != nil {
 			return
 		} else if n != valueLength {
-			err = errors.New("Memcached : Unknow error")
+			err = common.ErrMalformedResponse
 			return
 		}
 	}
@@ -190,8 +230,22 @@ func (parse *BinaryPorotolParse) parsePacket(conn *common.Conn) (p *packet, err
 	return
 }
 
-// Retrieval retrieve data from server
-func (parse *BinaryPorotolParse) Retrieval(keys []string) (items map[string]common.Item) {
+// Retrieval retrieve data from server, a missing key is left out of items
+func (parse *BinaryPorotolParse) Retrieval(ctx context.Context, keys []string) (items map[string]common.Item, err error) {
+	return parse.retrieval(ctx, GetKQ, GetK, nil, keys)
+}
+
+// RetrievalAndTouch retrieve data from server and update the expiration time of the items,
+// a missing key is left out of items
+func (parse *BinaryPorotolParse) RetrievalAndTouch(ctx context.Context, exptime uint32, keys []string) (items map[string]common.Item, err error) {
+	extras := make([]byte, 4)
+	binary.BigEndian.PutUint32(extras, exptime)
+
+	return parse.retrieval(ctx, GATKQ, GATK, extras, keys)
+}
+
+// retrieval send quiet for the first n-1 keys of a server and last for the last one
+func (parse *BinaryPorotolParse) retrieval(ctx context.Context, quiet, last uint8, extras []byte, keys []string) (items map[string]common.Item, err error) {
 	// result set of items
 	items = make(map[string]common.Item)
 
@@ -203,8 +257,12 @@ func (parse *BinaryPorotolParse) Retrieval(keys []string) (items map[string]comm
 
 	// if a key has the same index, they will put together.
 	for _, key := range keys {
-		if index, err := parse.pool.GetNode(key); err != nil {
+		if err = common.CheckKey(key); err != nil {
 			return
+		}
+
+		if index, err := parse.pool.GetNode(key); err != nil {
+			return items, err
 		} else {
 			// same index, same slice
 			if ks, ok := keyMap[index]; ok {
@@ -217,13 +275,15 @@ func (parse *BinaryPorotolParse) Retrieval(keys []string) (items map[string]comm
 	}
 
 	// send the get command line, and parse response
-	LoopOut:
 	for i, ks := range keyMap {
 		// get connect by key
-		conn, err := parse.pool.Get(ks[0])
+		conn, err := parse.acquire(ctx, ks[0])
 
-		if err != nil || conn.Index != i {
-			continue LoopOut
+		if err != nil {
+			return items, err
+		} else if conn.Index != i {
+			parse.release(conn, false)
+			return items, common.ErrServerNodesModified
 		}
 
 		loopCount := len(ks) - 1
@@ -232,66 +292,77 @@ func (parse *BinaryPorotolParse) Retrieval(keys []string) (items map[string]comm
 			reqPacket := &packet{
 				magic          : reqMagic,
 				keyLength      : uint16(len(ks[j])),
+				extrasLength   : uint8(len(extras)),
+				extras         : extras,
 				key            : []byte(ks[j]),
-				totalBodyLength: uint32(len(ks[j])),
+				totalBodyLength: uint32(len(extras) + len(ks[j])),
 			}
 
-			//the first n-1 being getkq, the last being a regular getk
+			//the first n-1 being quiet (getkq), the last being a regular one (getk)
 			if j < loopCount {
-				reqPacket.opcode = GetKQ
+				reqPacket.opcode = quiet
 			} else {
-				reqPacket.opcode = GetK
+				reqPacket.opcode = last
 			}
 
 			if err := parse.fillPacket(reqPacket, conn); err != nil {
 				parse.release(conn, true)
-				continue LoopOut
+				return items, err
 			}
 		}
 
 		// send content to memcached server
 		if err := conn.Flush(); err != nil {
 			parse.release(conn, true)
-			continue LoopOut
+			return items, err
 		}
 
-		// receive response from memcached server
+		// receive response from memcached server, quiet misses are not answered
+		var failed error
+
 		for j := 0; j <= loopCount; j++ {
 			resPacket, err := parse.parsePacket(conn)
 			if err != nil {
 				parse.release(conn, true)
-				continue LoopOut
+				return items, err
 			}
 
-			if err := parse.checkError(resPacket.statusOrVbucket); err != nil {
-				continue
-			}
+			err = parse.checkError(conn.Server(), resPacket.statusOrVbucket)
 
-			// fill item
-			item := &common.BinaryItem{BCas:resPacket.cas}
+			if err == nil {
+				// fill item
+				item := &common.BinaryItem{BCas:resPacket.cas}
 
-			if resPacket.keyLength > 0 {
-				item.BKey = string(resPacket.key)
-			}
+				if resPacket.keyLength > 0 {
+					item.BKey = string(resPacket.key)
+				}
 
-			if len(resPacket.value) > 0 {
-				item.BValue = make([]byte, len(resPacket.value))
-				copy(item.BValue, resPacket.value)
-			}
+				if len(resPacket.value) > 0 {
+					item.BValue = make([]byte, len(resPacket.value))
+					copy(item.BValue, resPacket.value)
+				}
 
-			if resPacket.extrasLength > 0 {
-				item.BFlags = binary.BigEndian.Uint32(resPacket.extras)
-			}
+				if resPacket.extrasLength > 0 {
+					item.BFlags = binary.BigEndian.Uint32(resPacket.extras)
+				}
 
-			items[item.BKey] = item
+				items[item.BKey] = item
+			} else if err != common.ErrCacheMiss {
+				// keep reading to stay in sync, report the failure after the last response
+				failed = err
+			}
 
-			// if the item is the last one, done!
-			if item.BKey == ks[loopCount] {
+			// the last key is always answered, done!
+			if resPacket.opcode == last {
 				break
 			}
 		}
 
 		parse.release(conn, false)
+
+		if failed != nil {
+			return items, failed
+		}
 	}
 
 	return
@@ -312,7 +383,7 @@ func (parse *BinaryPorotolParse) requestAndResponse(conn *common.Conn, reqPacket
 		parse.release(conn, true)
 		return
 	} else {
-		err = parse.checkError(resPacket.statusOrVbucket)
+		err = parse.checkError(conn.Server(), resPacket.statusOrVbucket)
 		parse.release(conn, false)
 	}
 
@@ -320,8 +391,8 @@ func (parse *BinaryPorotolParse) requestAndResponse(conn *common.Conn, reqPacket
 }
 
 // Set, Add, Replace
-func (parse *BinaryPorotolParse) Store(opr uint8, key string, flags uint32, exptime uint32, cas uint64, value []byte) (err error) {
-	conn, err := parse.pool.Get(key)
+func (parse *BinaryPorotolParse) Store(ctx context.Context, opr uint8, key string, flags uint32, exptime uint32, cas uint64, value []byte) (err error) {
+	conn, err := parse.acquire(ctx, key)
 	if err != nil {
 		return
 	}
@@ -342,11 +413,16 @@ func (parse *BinaryPorotolParse) Store(opr uint8, key string, flags uint32, expt
 
 	_, err = parse.requestAndResponse(conn, reqPacket)
 
+	// the text protocol answers NOT_STORED to an add of an existing key or a replace of a missing one
+	if (opr == Add && err == common.ErrCASConflict) || (opr == Replace && err == common.ErrCacheMiss) {
+		err = common.ErrNotStored
+	}
+
 	return
 }
 
-func (parse *BinaryPorotolParse) Deletion(key string) (err error) {
-	conn, err := parse.pool.Get(key)
+func (parse *BinaryPorotolParse) Deletion(ctx context.Context, key string) (err error) {
+	conn, err := parse.acquire(ctx, key)
 	if err != nil {
 		return
 	}
@@ -364,8 +440,8 @@ func (parse *BinaryPorotolParse) Deletion(key string) (err error) {
 	return
 }
 
-func (parse *BinaryPorotolParse) IncrOrDecr(opr uint8, key string, value uint64, exptime uint32) (v uint64, err error) {
-	conn, err := parse.pool.Get(key)
+func (parse *BinaryPorotolParse) IncrOrDecr(ctx context.Context, opr uint8, key string, value uint64, exptime uint32) (v uint64, err error) {
+	conn, err := parse.acquire(ctx, key)
 	if err != nil {
 		return
 	}
@@ -391,8 +467,8 @@ func (parse *BinaryPorotolParse) IncrOrDecr(opr uint8, key string, value uint64,
 	return
 }
 
-func (parse *BinaryPorotolParse) AppendOrPrepend(opr uint8, key string, value []byte) (err error) {
-	conn, err := parse.pool.Get(key)
+func (parse *BinaryPorotolParse) AppendOrPrepend(ctx context.Context, opr uint8, key string, value []byte) (err error) {
+	conn, err := parse.acquire(ctx, key)
 	if err != nil {
 		return
 	}
@@ -411,8 +487,8 @@ func (parse *BinaryPorotolParse) AppendOrPrepend(opr uint8, key string, value []
 	return
 }
 
-func (parse *BinaryPorotolParse) Touch(key string, exptime uint32) (err error) {
-	conn, err := parse.pool.Get(key)
+func (parse *BinaryPorotolParse) Touch(ctx context.Context, key string, exptime uint32) (err error) {
+	conn, err := parse.acquire(ctx, key)
 	if err != nil {
 		return
 	}
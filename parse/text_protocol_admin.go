@@ -0,0 +1,140 @@
+package parse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ningjh/memcached/common"
+)
+
+// FlushAll invalidate all existing items of the i-th server, after delay seconds
+func (parse *TextProtocolParse) FlushAll(ctx context.Context, i int, delay uint32) error {
+	response, err := parse.simpleCommand(ctx, i, fmt.Sprintf("flush_all %d%s", delay, crlf))
+
+	if err == nil && response != "OK" {
+		err = common.ErrMalformedResponse
+	}
+
+	return err
+}
+
+// Version return the version string of the i-th server
+func (parse *TextProtocolParse) Version(ctx context.Context, i int) (string, error) {
+	response, err := parse.simpleCommand(ctx, i, "version"+crlf)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(response, "VERSION ") {
+		return "", common.ErrMalformedResponse
+	}
+
+	return strings.TrimPrefix(response, "VERSION "), nil
+}
+
+// Verbosity set the verbosity level of the logging output of the i-th server
+func (parse *TextProtocolParse) Verbosity(ctx context.Context, i int, level uint32) error {
+	response, err := parse.simpleCommand(ctx, i, fmt.Sprintf("verbosity %d%s", level, crlf))
+
+	if err == nil && response != "OK" {
+		err = common.ErrMalformedResponse
+	}
+
+	return err
+}
+
+// Stats return the statistics of the i-th server, args selects a group such as "slabs" or "items"
+func (parse *TextProtocolParse) Stats(ctx context.Context, i int, args ...string) (stats map[string]string, err error) {
+	conn, err := parse.acquireServer(ctx, i)
+	if err != nil {
+		return
+	}
+
+	command := strings.Join(append([]string{"stats"}, args...), whitespace) + crlf
+
+	// send datas to memcached server
+	if _, err = conn.Write([]byte(command)); err != nil {
+		parse.release(conn, true)
+		return nil, err
+	}
+
+	stats = make(map[string]string)
+
+	// parse response, 'STAT <name> <value>' lines end with 'END'
+	for {
+		line, err := conn.ReadString(lf)
+		if err != nil {
+			parse.release(conn, true)
+			return nil, err
+		}
+
+		if err = parse.checkError(conn.Server(), line); err != nil {
+			parse.release(conn, false)
+			return nil, err
+		}
+
+		line = strings.Replace(line, crlf, "", -1)
+
+		if line == "END" || line == "RESET" || line == "OK" {
+			break
+		}
+
+		params := strings.SplitN(line, whitespace, 3)
+		if len(params) < 2 || params[0] != "STAT" {
+			parse.release(conn, true)
+			return nil, common.ErrMalformedResponse
+		}
+
+		if len(params) == 3 {
+			stats[params[1]] = params[2]
+		} else {
+			stats[params[1]] = ""
+		}
+	}
+
+	// put the connect back to the pool
+	parse.release(conn, false)
+
+	return
+}
+
+// simpleCommand send command to the i-th server and return the response line without crlf
+func (parse *TextProtocolParse) simpleCommand(ctx context.Context, i int, command string) (string, error) {
+	conn, err := parse.acquireServer(ctx, i)
+	if err != nil {
+		return "", err
+	}
+
+	// send datas to memcached server
+	if _, err := conn.Write([]byte(command)); err != nil {
+		parse.release(conn, true)
+		return "", err
+	}
+
+	// parse the response from server
+	response, err := conn.ReadString(lf)
+	if err != nil {
+		parse.release(conn, true)
+		return "", err
+	}
+
+	err = parse.checkError(conn.Server(), response)
+
+	// put the connect back to the pool
+	parse.release(conn, false)
+
+	return strings.Replace(response, crlf, "", -1), err
+}
+
+// acquireServer get a connect to the i-th server and bind ctx to it
+func (parse *TextProtocolParse) acquireServer(ctx context.Context, i int) (*common.Conn, error) {
+	conn, err := parse.pool.GetServer(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.Bind(ctx)
+
+	return conn, nil
+}
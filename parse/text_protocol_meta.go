@@ -0,0 +1,203 @@
+package parse
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/ningjh/memcached/common"
+)
+
+// MetaGet get the item with key, flags select what is returned (mg)
+func (parse *TextProtocolParse) MetaGet(ctx context.Context, key string, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return parse.meta(ctx, "mg", key, nil, flags)
+}
+
+// MetaSet store value with key, flags select the mode and what is returned (ms)
+func (parse *TextProtocolParse) MetaSet(ctx context.Context, key string, value []byte, flags *common.MetaFlags) (*common.MetaResult, error) {
+	if value == nil {
+		value = []byte{}
+	}
+
+	return parse.meta(ctx, "ms", key, value, flags)
+}
+
+// MetaDelete delete or, with the I flag, invalidate the item with key (md)
+func (parse *TextProtocolParse) MetaDelete(ctx context.Context, key string, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return parse.meta(ctx, "md", key, nil, flags)
+}
+
+// MetaArithmetic increment or decrement the item with key, the new value is returned with the v flag (ma)
+func (parse *TextProtocolParse) MetaArithmetic(ctx context.Context, key string, flags *common.MetaFlags) (*common.MetaResult, error) {
+	return parse.meta(ctx, "ma", key, nil, flags)
+}
+
+// MetaNoop send a meta no-op to the i-th server (mn)
+func (parse *TextProtocolParse) MetaNoop(ctx context.Context, i int) error {
+	response, err := parse.simpleCommand(ctx, i, "mn"+crlf)
+
+	if err == nil && response != "MN" {
+		err = common.ErrMalformedResponse
+	}
+
+	return err
+}
+
+// meta send a meta command and parse its response.
+// With the q flag a mn follows the command, so a suppressed response is told apart from a slow one.
+func (parse *TextProtocolParse) meta(ctx context.Context, opr string, key string, value []byte, flags *common.MetaFlags) (result *common.MetaResult, err error) {
+	// get a connect from the pool
+	conn, err := parse.acquire(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// create command, 'ms <key> <datalen> <flags>*' or '<opr> <key> <flags>*'
+	params := []string{opr, key}
+	if value != nil {
+		params = append(params, strconv.Itoa(len(value)))
+	}
+	if s := flags.String(); s != "" {
+		params = append(params, s)
+	}
+
+	data := []byte(strings.Join(params, whitespace) + crlf)
+	if value != nil {
+		data = mergeBytes(data, value, []byte(crlf))
+	}
+
+	quiet := flags.Has('q')
+	if quiet {
+		data = mergeBytes(data, []byte("mn"+crlf))
+	}
+
+	// send datas to memcached server
+	if _, err = conn.Write(data); err != nil {
+		parse.release(conn, true)
+		return nil, err
+	}
+
+	// parse the response from server
+	result, err = parse.readMeta(conn)
+	if err != nil {
+		parse.release(conn, true)
+		return nil, err
+	}
+
+	if quiet {
+		if result.Status == "MN" {
+			// the uninteresting response was suppressed
+			if opr == "mg" {
+				result.Status = "EN"
+			} else {
+				result.Status = "HD"
+			}
+		} else if line, e := conn.ReadString(lf); e != nil || strings.Replace(line, crlf, "", -1) != "MN" {
+			parse.release(conn, true)
+			if e == nil {
+				e = common.ErrMalformedResponse
+			}
+			return nil, e
+		}
+	}
+
+	// put the connect back to the pool
+	parse.release(conn, false)
+
+	return result, metaError(result.Status)
+}
+
+// readMeta read a meta response, '<status> <flags>*' or 'VA <size> <flags>*' followed by the value
+func (parse *TextProtocolParse) readMeta(conn *common.Conn) (*common.MetaResult, error) {
+	line, err := conn.ReadString(lf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = parse.checkError(conn.Server(), line); err != nil {
+		return nil, err
+	}
+
+	params := strings.Split(strings.Replace(line, crlf, "", -1), whitespace)
+	result := &common.MetaResult{Status: params[0]}
+
+	switch result.Status {
+	case "VA":
+		if len(params) < 2 {
+			return nil, common.ErrMalformedResponse
+		}
+
+		size, err := strconv.Atoi(params[1])
+		if err != nil || size < 0 {
+			return nil, common.ErrMalformedResponse
+		}
+
+		// read value and crlf
+		value := make([]byte, size+2)
+		if _, err = conn.ReadFull(value); err != nil {
+			return nil, err
+		}
+
+		result.Value = value[:size]
+		params = params[2:]
+	case "HD", "EN", "NS", "EX", "NF", "MN":
+		params = params[1:]
+	default:
+		return nil, common.ErrMalformedResponse
+	}
+
+	for _, p := range params {
+		if p == "" {
+			continue
+		}
+
+		token := p[1:]
+
+		switch p[0] {
+		case 'c':
+			result.Cas, err = strconv.ParseUint(token, 10, 64)
+		case 'f':
+			var f uint64
+			f, err = strconv.ParseUint(token, 10, 32)
+			result.Flags = uint32(f)
+		case 't':
+			result.TTL, err = strconv.ParseInt(token, 10, 64)
+		case 'l':
+			result.LastAccess, err = strconv.ParseUint(token, 10, 64)
+		case 'h':
+			result.HitBefore = token == "1"
+		case 's':
+			result.Size, err = strconv.ParseUint(token, 10, 64)
+		case 'k':
+			result.Key = token
+		case 'O':
+			result.Opaque = token
+		case 'W':
+			result.Win = true
+		case 'X':
+			result.Stale = true
+		case 'Z':
+			result.AlreadyWon = true
+		}
+
+		if err != nil {
+			return nil, common.ErrMalformedResponse
+		}
+	}
+
+	return result, nil
+}
+
+// metaError map the status of a meta response to the errors of package common
+func metaError(status string) error {
+	switch status {
+	case "EN", "NF":
+		return common.ErrCacheMiss
+	case "NS":
+		return common.ErrNotStored
+	case "EX":
+		return common.ErrCASConflict
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@
 package parse
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -27,9 +28,9 @@ func NewTextProtocolParse(p pool.Pool, c *config.Config) *TextProtocolParse {
 }
 
 // Store ask the server to store some data identified by a key
-func (parse *TextProtocolParse) Store(opr string, key string, flags uint32, exptime uint32, cas uint64, value []byte) error {
+func (parse *TextProtocolParse) Store(ctx context.Context, opr string, key string, flags uint32, exptime uint32, cas uint64, value []byte) error {
 	// get a connect from the pool
-	conn, err := parse.pool.Get(key)
+	conn, err := parse.acquire(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -53,7 +54,7 @@ func (parse *TextProtocolParse) Store(opr string, key string, flags uint32, expt
 		return err
 	}
 
-	err = parse.checkError(response)
+	err = parse.checkError(conn.Server(), response)
 
 	// put the connect back to the pool
 	parse.release(conn, false)
@@ -62,7 +63,16 @@ func (parse *TextProtocolParse) Store(opr string, key string, flags uint32, expt
 }
 
 // Retrieval retrieve data from server
-func (parse *TextProtocolParse) Retrieval(opr string, keys []string) (items map[string]common.Item, err error) {
+func (parse *TextProtocolParse) Retrieval(ctx context.Context, opr string, keys []string) (items map[string]common.Item, err error) {
+	return parse.retrieval(ctx, opr, 0, keys)
+}
+
+// RetrievalAndTouch retrieve data from server and update the expiration time of the items, opr is gat or gats
+func (parse *TextProtocolParse) RetrievalAndTouch(ctx context.Context, opr string, exptime uint32, keys []string) (items map[string]common.Item, err error) {
+	return parse.retrieval(ctx, opr, exptime, keys)
+}
+
+func (parse *TextProtocolParse) retrieval(ctx context.Context, opr string, exptime uint32, keys []string) (items map[string]common.Item, err error) {
 	// result set
 	items = make(map[string]common.Item)
 
@@ -74,6 +84,10 @@ func (parse *TextProtocolParse) Retrieval(opr string, keys []string) (items map[
 
 	// if a key has the same index, they will put together.
 	for _, key := range keys {
+		if err = common.CheckKey(key); err != nil {
+			return
+		}
+
 		// calculate the key's index
 		index, err := parse.pool.GetNode(key)
 
@@ -93,17 +107,18 @@ func (parse *TextProtocolParse) Retrieval(opr string, keys []string) (items map[
 	// send the get or gets command line, and parse response
 	for i, ks := range keyMap {
 		// get connect by key
-		conn, err := parse.pool.Get(ks[0])
+		conn, err := parse.acquire(ctx, ks[0])
 		if err != nil {
 			return items, err
 		} else {
 			if conn.Index != i {
-				return items, fmt.Errorf("Memcached : server nodes had been modified")
+				parse.release(conn, false)
+				return items, common.ErrServerNodesModified
 			}
 		}
 
 		// create command
-		command := parse.createCommand(opr, strings.Join(ks, whitespace), nil, nil, nil, nil)
+		command := parse.createCommand(opr, strings.Join(ks, whitespace), nil, exptime, nil, nil)
 
 		// send datas to memcached server
 		if _, err := conn.Write(command); err != nil {
@@ -114,7 +129,7 @@ func (parse *TextProtocolParse) Retrieval(opr string, keys []string) (items map[
 		// parse response
 		for {
 			if line, err := conn.ReadString(lf); err == nil {
-				if err = parse.checkError(line); err != nil {
+				if err = parse.checkError(conn.Server(), line); err != nil {
 					parse.release(conn, true)
 					return items, err
 				}
@@ -177,9 +192,9 @@ func (parse *TextProtocolParse) Retrieval(opr string, keys []string) (items map[
 }
 
 // Deletion delete the item with key
-func (parse *TextProtocolParse) Deletion(key string) error {
+func (parse *TextProtocolParse) Deletion(ctx context.Context, key string) error {
 	// get a connect from the pool
-	conn, err := parse.pool.Get(key)
+	conn, err := parse.acquire(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -200,7 +215,7 @@ func (parse *TextProtocolParse) Deletion(key string) error {
 		return err
 	}
 
-	err = parse.checkError(response)
+	err = parse.checkError(conn.Server(), response)
 
 	// put the connect back to the pool
 	parse.release(conn, false)
@@ -209,9 +224,9 @@ func (parse *TextProtocolParse) Deletion(key string) error {
 }
 
 // IncrOrDecr increment or decrement an item, and return new value of the item's data
-func (parse *TextProtocolParse) IncrOrDecr(opr string, key string, value uint64) (uint64, error) {
+func (parse *TextProtocolParse) IncrOrDecr(ctx context.Context, opr string, key string, value uint64) (uint64, error) {
 	// get a connect from the pool
-	conn, err := parse.pool.Get(key)
+	conn, err := parse.acquire(ctx, key)
 	if err != nil {
 		return 0, err
 	}
@@ -232,7 +247,7 @@ func (parse *TextProtocolParse) IncrOrDecr(opr string, key string, value uint64)
 		return 0, err
 	}
 
-	err = parse.checkError(response)
+	err = parse.checkError(conn.Server(), response)
 
 	// put the connect back to the pool
 	parse.release(conn, false)
@@ -245,9 +260,9 @@ func (parse *TextProtocolParse) IncrOrDecr(opr string, key string, value uint64)
 }
 
 // Touch touch an item
-func (parse *TextProtocolParse) Touch(key string, exptime uint32) error {
+func (parse *TextProtocolParse) Touch(ctx context.Context, key string, exptime uint32) error {
 	// get a connect from the pool
-	conn, err := parse.pool.Get(key)
+	conn, err := parse.acquire(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -268,7 +283,7 @@ func (parse *TextProtocolParse) Touch(key string, exptime uint32) error {
 		return err
 	}
 
-	err = parse.checkError(response)
+	err = parse.checkError(conn.Server(), response)
 
 	// put the connect back to the pool
 	parse.release(conn, false)
@@ -276,35 +291,70 @@ func (parse *TextProtocolParse) Touch(key string, exptime uint32) error {
 	return err
 }
 
+// acquire get a connect from the pool and bind ctx to it
+func (parse *TextProtocolParse) acquire(ctx context.Context, key string) (*common.Conn, error) {
+	if err := common.CheckKey(key); err != nil {
+		return nil, err
+	}
+
+	conn, err := parse.pool.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.Bind(ctx)
+
+	return conn, nil
+}
+
 func (parse *TextProtocolParse) release(conn *common.Conn, doClose bool) {
+	// a cancelled request may leave a half-read response, never put it back to the pool
+	if err := conn.Unbind(); err != nil {
+		doClose = true
+	}
+
 	if doClose {
-		go conn.Close()
+		parse.pool.Discard(conn)
 	} else {
-		go parse.pool.Release(conn)
+		parse.pool.Release(conn)
 	}
 }
 
-func (parse *TextProtocolParse) checkError(s string) (err error) {
+// checkError map an error line from server to the errors of package common.
+func (parse *TextProtocolParse) checkError(server string, s string) (err error) {
 	if len(strings.Trim(s, whitespace)) == 0 {
-		err = fmt.Errorf("Memcached : empty value error")
+		err = common.ErrMalformedResponse
 		return
 	}
 
-	result := strings.Split(strings.Replace(s, crlf, "", -1), whitespace)
+	result := strings.SplitN(strings.Replace(s, crlf, "", -1), whitespace, 2)
+
+	var msg string
+	if len(result) == 2 {
+		msg = result[1]
+	}
 
 	switch result[0] {
 	case "ERROR":
-		err = fmt.Errorf("Memcached : nonexistent command name")
+		err = common.ErrUnknownCommand
 	case "CLIENT_ERROR":
-		err = fmt.Errorf("Memcached : %s", result[1])
+		if strings.Contains(msg, "non-numeric") {
+			err = common.ErrNonNumeric
+		} else {
+			err = &common.ErrClientError{Server: server, Msg: msg}
+		}
 	case "SERVER_ERROR":
-		err = fmt.Errorf("Memcached : %s", result[1])
+		if strings.Contains(msg, "too large") {
+			err = common.ErrValueTooLarge
+		} else {
+			err = &common.ErrServerError{Server: server, Msg: msg}
+		}
 	case "NOT_STORED":
-		err = fmt.Errorf("Memcached : the command wasn't met")
+		err = common.ErrNotStored
 	case "EXISTS":
-		err = fmt.Errorf("Memcached : the item has been modified since you last fetched it")
+		err = common.ErrCASConflict
 	case "NOT_FOUND":
-		err = fmt.Errorf("Memcached : the item did not exist")
+		err = common.ErrCacheMiss
 	}
 
 	return
@@ -318,6 +368,8 @@ func (parse *TextProtocolParse) createCommand(opr string, key, flags, exptime, c
 		command = []byte(fmt.Sprintf("%s %s %d %d %d %d %s", opr, key, flags, exptime, value, cas, crlf))
 	case "get", "gets":
 		command = []byte(fmt.Sprintf("%s %s %s", opr, key, crlf))
+	case "gat", "gats":
+		command = []byte(fmt.Sprintf("%s %d %s %s", opr, exptime, key, crlf))
 	case "delete":
 		command = []byte(fmt.Sprintf("%s %s %s", opr, key, crlf))
 	case "incr", "decr":
@@ -8,80 +8,239 @@ import (
 	"github.com/ningjh/memcached/factory"
 	"github.com/ningjh/memcached/selector"
 
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Pool interface {
-	Get(string) (*common.Conn, error)
+	Get(context.Context, string) (*common.Conn, error)
+	GetServer(context.Context, int) (*common.Conn, error)
 	Release(*common.Conn)
+	Discard(*common.Conn)
 	GetNode(string) (int, error)
+	Close() error
+	Shutdown(context.Context) error
+	Stats() map[string]ServerStats
 }
 
 type ConnectionPool struct {
-	pools      []chan *common.Conn
+	pools      []chan *common.Conn         //idle connections of each server
+	slots      []chan struct{}             //one token per open connection of each server, nil without MaxOpenConns
+	stats      []*serverStats
+	done       chan struct{}               //closed by Close, wakes up the waiting Get calls and stops the background goroutines
+	workers    sync.WaitGroup              //the reaper and the refresh goroutines
 	config     *config.Config
 	factory    *factory.ConnectionFactory
-	consistent *selector.Consistent
+	selector   config.ServerSelector
+	closed     bool
+	draining   bool                          //set by Shutdown, no more connections are handed out
+	mu         sync.RWMutex                  //guards closed and draining against the channels
+	inflight   sync.WaitGroup                //one per Get that has not been released or discarded yet
+	active     map[*common.Conn]net.Conn     //connections handed out by Get
+	activeMu   sync.Mutex
 }
 
-// return a ConnectionPool instance, and for each server initializes a connection pool
+// return a ConnectionPool instance, and for each server initializes a connection pool.
+// The servers are dialed in parallel, InitTimeout bounds the whole pre-fill.
+// With LazyStart the servers that can not be dialed are marked down and added back by the refresh task,
+// pool.New only fails if fewer than MinHealthyServers are reachable.
 func New(config *config.Config) (Pool, error) {
 	if len(config.Servers) == 0 {
-		return nil, errors.New("Memcached : Memcached Servers must not empty")
+		return nil, common.ErrNoServers
+	}
+
+	if err := checkServers(config); err != nil {
+		return nil, err
+	}
+
+	sel := config.Selector
+	if sel == nil {
+		sel = selector.NewConsistent(config)
 	}
 
 	pool := &ConnectionPool{
 		pools:      make([]chan *common.Conn, 0, len(config.Servers)),
+		slots:      make([]chan struct{}, len(config.Servers)),
+		stats:      make([]*serverStats, len(config.Servers)),
+		done:       make(chan struct{}),
 		config:     config,
 		factory:    factory.NewConnectionFactory(config),
-		consistent: selector.NewConsistent(config),
+		selector:   sel,
+		active:     make(map[*common.Conn]net.Conn),
+	}
+
+	// MaxIdleConns defaults to InitConns
+	maxIdle := int(pool.config.InitConns)
+	if pool.config.MaxIdleConns > 0 {
+		maxIdle = pool.config.MaxIdleConns
 	}
 
 	for i := 0; i < len(pool.config.Servers); i++ {
-		pool.pools = append(pool.pools, make(chan *common.Conn, pool.config.InitConns))
+		pool.pools = append(pool.pools, make(chan *common.Conn, maxIdle))
+		pool.stats[i] = new(serverStats)
 
-		for j := 0; j < int(pool.config.InitConns / 2 + 1); j++ {
-			conn, err := pool.factory.NewTcpConnect(pool.config.Servers[i], i)
+		if pool.config.MaxOpenConns > 0 {
+			pool.slots[i] = make(chan struct{}, pool.config.MaxOpenConns)
+		}
+	}
 
-			if err != nil {
-				return nil, err
-			} else {
-				pool.pools[i] <- conn
-			}
+	var firstErr error
+	healthy := 0
+	errs := pool.prefill()
+
+	for i, err := range errs {
+		if err == nil {
+			healthy++
+			continue
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+
+		pool.clean(i)
+	}
+
+	if firstErr != nil && !pool.config.LazyStart {
+		pool.Close()
+		return nil, firstErr
+	}
+
+	if healthy < pool.config.MinHealthyServers {
+		pool.Close()
+		return nil, fmt.Errorf("%w : %d of %d servers reachable : %v", common.ErrTooFewServers, healthy, len(pool.config.Servers), firstErr)
+	}
+
+	if err := pool.selector.SetServers(pool.config.Servers...); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	for i := range pool.config.Servers {
+		if errs[i] != nil {
+			pool.selector.MarkDown(i)
 		}
+	}
 
-		pool.consistent.Add(pool.config.Servers[i])
+	if interval := pool.config.RefreshHashIntervalInSecond; interval > 0 {
+		pool.workers.Add(1)
+		go pool.refresh(time.Second * time.Duration(interval))
 	}
 
-	pool.consistent.RefreshTicker()
+	if interval := pool.reapInterval(); interval > 0 {
+		pool.workers.Add(1)
+		go pool.reap(interval)
+	}
 
 	return pool, nil
 }
 
-// GetNode get consistent hashing node
+// checkServers parse every server of c, a malformed weight is reported before any dial
+func checkServers(c *config.Config) error {
+	for _, server := range c.Servers {
+		if _, _, err := config.ParseServer(server); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prefill dial the initial connections of every server in parallel and return the error of each server
+func (pool *ConnectionPool) prefill() []error {
+	ctx := context.Background()
+
+	if pool.config.InitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Millisecond*time.Duration(pool.config.InitTimeout))
+		defer cancel()
+	}
+
+	errs := make([]error, len(pool.config.Servers))
+
+	var wg sync.WaitGroup
+	for i := range pool.config.Servers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			n := int(pool.config.InitConns / 2 + 1)
+			if n > cap(pool.pools[i]) {
+				n = cap(pool.pools[i])
+			}
+			if pool.slots[i] != nil && n > cap(pool.slots[i]) {
+				n = cap(pool.slots[i])
+			}
+
+			for j := 0; j < n; j++ {
+				if pool.slots[i] != nil {
+					pool.slots[i] <- struct{}{}
+				}
+
+				conn, err := pool.dial(ctx, i)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+
+				pool.pools[i] <- conn
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// GetNode get the server of key from the selector
 func (pool *ConnectionPool) GetNode(key string) (int, error) {
-	return pool.consistent.Get(key)
+	return pool.selector.PickServer(key)
 }
 
-// Get get connect with key
-func (pool *ConnectionPool) Get(key string) (conn *common.Conn, err error) {
+// Get get connect with key, ctx bounds the dial of a new connection
+func (pool *ConnectionPool) Get(ctx context.Context, key string) (conn *common.Conn, err error) {
 	var i, j int
 
+	if !pool.begin() {
+		return nil, common.ErrClientClosed
+	}
+
+	defer func() {
+		if err == nil {
+			pool.track(conn)
+		} else {
+			pool.inflight.Done()
+		}
+	}()
+
 	for j = 0; j < len(pool.config.Servers); j++ {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+
 		if i, err = pool.GetNode(key); err == nil {
-			if conn, err = pool.get(i); err == nil {
-				if conn.Connected() {
-					break
-				} else {
-					err = errors.New("Memcached : can not connect to Memcached server")
-				}
+			if conn, err = pool.get(ctx, i); err == nil {
+				break
 			}
 
-			if conn != nil {
-				conn.Close()
+			// the server is not to blame for a cancelled request or an exhausted pool
+			if ctx.Err() != nil {
+				err = ctx.Err()
+				break
+			}
+
+			// nor for credentials it rejects, another server would reject them as well
+			if err == common.ErrPoolTimeout || err == common.ErrClientClosed || errors.Is(err, common.ErrAuthFailed) {
+				break
 			}
 			
-			pool.consistent.Remove(pool.config.Servers[i])
+			pool.selector.MarkDown(i)
 
 			// clean the pool
 			pool.clean(i)
@@ -93,36 +252,282 @@ func (pool *ConnectionPool) Get(key string) (conn *common.Conn, err error) {
 	return
 }
 
+// GetServer get connect to the i-th server of config.Servers, without falling back to another server
+func (pool *ConnectionPool) GetServer(ctx context.Context, i int) (conn *common.Conn, err error) {
+	if i < 0 || i >= len(pool.pools) {
+		return nil, common.ErrUnknownServer
+	}
+
+	if !pool.begin() {
+		return nil, common.ErrClientClosed
+	}
+
+	defer func() {
+		if err == nil {
+			pool.track(conn)
+		} else {
+			pool.inflight.Done()
+		}
+	}()
+
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	conn, err = pool.get(ctx, i)
+
+	return
+}
+
 // Release put connect back to the pool
 func (pool *ConnectionPool) Release(conn *common.Conn) {
 	if conn != nil {
+		pool.untrack(conn)
 		pool.release(conn.Index, conn)
 	}
 }
 
-func (pool *ConnectionPool) get(i int) (*common.Conn, error) {
-	select {
-	case conn := <-pool.pools[i]:
+// Discard close a connection got from Get instead of putting it back, for example after a network error
+func (pool *ConnectionPool) Discard(conn *common.Conn) {
+	if conn != nil {
+		pool.untrack(conn)
+		atomic.AddInt64(&pool.stats[conn.Index].closedOnError, 1)
+		pool.closeConn(conn)
+	}
+}
+
+// get take an idle connection of the i-th server or dial a new one.
+// With MaxOpenConns reached it waits for a connection to be released or closed,
+// until ctx is done or WaitTimeout expires.
+// An idle connection that is expired or fails its health check is closed and the next one is tried.
+func (pool *ConnectionPool) get(ctx context.Context, i int) (conn *common.Conn, err error) {
+	start := time.Now()
+
+	defer func() {
+		if err == nil {
+			pool.stats[i].acquire(time.Since(start))
+		}
+	}()
+
+	if conn := pool.idle(ctx, i); conn != nil {
 		return conn, nil
+	}
+
+	slots := pool.slots[i]
+	if slots == nil {
+		return pool.dial(ctx, i)
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return pool.dial(ctx, i)
 	default:
-		return pool.factory.NewTcpConnect(pool.config.Servers[i], i)
+	}
+
+	// every connection is in use
+	waitStart := time.Now()
+	defer func() {
+		pool.stats[i].wait(time.Since(waitStart))
+	}()
+
+	var timeout <-chan time.Time
+	if pool.config.WaitTimeout > 0 {
+		timer := time.NewTimer(time.Millisecond * time.Duration(pool.config.WaitTimeout))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		select {
+		case conn := <-pool.pools[i]:
+			if !pool.usable(ctx, conn, time.Now()) {
+				pool.closeConn(conn)
+				continue
+			}
+			return conn, nil
+		case slots <- struct{}{}:
+			return pool.dial(ctx, i)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, common.ErrPoolTimeout
+		case <-pool.done:
+			return nil, common.ErrClientClosed
+		}
+	}
+}
+
+// idle take a usable idle connection of the i-th server, the others are closed on the way
+func (pool *ConnectionPool) idle(ctx context.Context, i int) *common.Conn {
+	now := time.Now()
+
+	for {
+		select {
+		case conn := <-pool.pools[i]:
+			if pool.usable(ctx, conn, now) {
+				return conn
+			}
+			pool.closeConn(conn)
+		default:
+			return nil
+		}
+	}
+}
+
+// dial open a new connection to the i-th server, its slot must have been taken
+func (pool *ConnectionPool) dial(ctx context.Context, i int) (*common.Conn, error) {
+	conn, err := pool.factory.NewTcpConnectContext(ctx, pool.config.Addr(i), i)
+	pool.stats[i].dial(err)
+
+	if err != nil {
+		if pool.slots[i] != nil {
+			<-pool.slots[i]
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// closeConn close a connection of the pool and free its slot
+func (pool *ConnectionPool) closeConn(conn *common.Conn) {
+	i := conn.Index
+
+	conn.Close()
+	atomic.AddInt64(&pool.stats[i].open, -1)
+
+	if pool.slots[i] != nil {
+		<-pool.slots[i]
 	}
 }
 
 func (pool *ConnectionPool) release(i int, conn *common.Conn) {
+	conn.UsedAt = time.Now()
+	pool.putIdle(i, conn)
+}
+
+// putIdle put back an idle connection without touching its last-used time
+func (pool *ConnectionPool) putIdle(i int, conn *common.Conn) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if pool.closed {
+		pool.closeConn(conn)
+		return
+	}
+
 	select {
 	case pool.pools[i] <- conn:
 	default:
-		conn.Close()
+		atomic.AddInt64(&pool.stats[i].discardedFull, 1)
+		pool.closeConn(conn)
+	}
+}
+
+// Close stop the background goroutines and close every pooled connection.
+// Connections still in use are closed when they are released, later Get calls return ErrClientClosed.
+func (pool *ConnectionPool) Close() error {
+	pool.mu.Lock()
+
+	if pool.closed {
+		pool.mu.Unlock()
+		return nil
+	}
+
+	pool.closed = true
+	close(pool.done)
+
+	for i := range pool.pools {
+		pool.clean(i)
+	}
+
+	pool.mu.Unlock()
+
+	pool.workers.Wait()
+
+	if closer, ok := pool.selector.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Shutdown stop handing out connections, wait for the connections in use to be released and close the pool.
+// If ctx is done first, the connections in use are interrupted, the pool is closed and ctx.Err() is returned.
+func (pool *ConnectionPool) Shutdown(ctx context.Context) error {
+	pool.mu.Lock()
+	pool.draining = true
+	pool.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		pool.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return pool.Close()
+	case <-ctx.Done():
+		pool.interrupt()
+		pool.Close()
+		return ctx.Err()
+	}
+}
+
+// begin register a Get, it returns false if the pool is closed or draining
+func (pool *ConnectionPool) begin() bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if pool.closed || pool.draining {
+		return false
+	}
+
+	pool.inflight.Add(1)
+
+	return true
+}
+
+func (pool *ConnectionPool) track(conn *common.Conn) {
+	pool.activeMu.Lock()
+	pool.active[conn] = conn.Conn
+	pool.activeMu.Unlock()
+
+	atomic.AddInt64(&pool.stats[conn.Index].inUse, 1)
+}
+
+// untrack end the Get that handed out conn, a connection that is not in use is ignored
+func (pool *ConnectionPool) untrack(conn *common.Conn) {
+	pool.activeMu.Lock()
+	_, ok := pool.active[conn]
+	delete(pool.active, conn)
+	pool.activeMu.Unlock()
+
+	if ok {
+		atomic.AddInt64(&pool.stats[conn.Index].inUse, -1)
+		pool.inflight.Done()
+	}
+}
+
+// interrupt close the sockets of the connections in use, their blocked reads and writes fail at once
+func (pool *ConnectionPool) interrupt() {
+	pool.activeMu.Lock()
+	defer pool.activeMu.Unlock()
+
+	for _, c := range pool.active {
+		c.Close()
 	}
 }
 
 func (pool *ConnectionPool) clean(i int) {
-	for t := 0; t < int(pool.config.InitConns); t++ {
-		if conn, err := pool.get(i); err == nil {
-			conn.Close()
-		} else {
-			break
+	for t := 0; t < cap(pool.pools[i]); t++ {
+		select {
+		case conn := <-pool.pools[i]:
+			pool.closeConn(conn)
+		default:
+			return
 		}
 	}
 }
\ No newline at end of file
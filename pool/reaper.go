@@ -0,0 +1,144 @@
+package pool
+
+import (
+	"github.com/ningjh/memcached/common"
+
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// default interval of the reaper when only MinIdleConns is set
+	defaultReapInterval = time.Second
+
+	// default idle time after which a connection is checked before reuse
+	defaultHealthCheckAfter = time.Second
+)
+
+// expired report whether conn has been idle longer than IdleTimeout or is older than MaxConnLifetime
+func (pool *ConnectionPool) expired(conn *common.Conn, now time.Time) bool {
+	if t := pool.config.IdleTimeout; t > 0 && now.Sub(conn.UsedAt) > time.Millisecond*time.Duration(t) {
+		return true
+	}
+
+	if t := pool.config.MaxConnLifetime; t > 0 && now.Sub(conn.CreatedAt) > time.Millisecond*time.Duration(t) {
+		return true
+	}
+
+	return false
+}
+
+// usable report whether an idle connection can be handed out: it is not expired and,
+// if it has been idle longer than HealthCheckAfter, it passes Alive and Ping.
+// A failed health check is counted in ClosedOnError, the caller closes the connection.
+func (pool *ConnectionPool) usable(ctx context.Context, conn *common.Conn, now time.Time) bool {
+	if pool.expired(conn, now) {
+		return false
+	}
+
+	after := defaultHealthCheckAfter
+	if pool.config.HealthCheckAfter != 0 {
+		after = time.Millisecond * time.Duration(pool.config.HealthCheckAfter)
+	}
+
+	if after < 0 || now.Sub(conn.UsedAt) <= after {
+		return true
+	}
+
+	conn.Bind(ctx)
+	ok := conn.Connected()
+
+	if conn.Unbind() != nil || !ok {
+		atomic.AddInt64(&pool.stats[conn.Index].closedOnError, 1)
+		return false
+	}
+
+	return true
+}
+
+// reapInterval return how often the reaper runs, half of the shortest timeout, 0 if it is not needed
+func (pool *ConnectionPool) reapInterval() (interval time.Duration) {
+	for _, t := range []int64{pool.config.IdleTimeout, pool.config.MaxConnLifetime} {
+		if d := time.Millisecond * time.Duration(t) / 2; d > 0 && (interval == 0 || d < interval) {
+			interval = d
+		}
+	}
+
+	if interval == 0 && pool.config.MinIdleConns > 0 {
+		interval = defaultReapInterval
+	}
+
+	return
+}
+
+// reap close the expired idle connections and top the pools up to MinIdleConns until the pool is closed
+func (pool *ConnectionPool) reap(interval time.Duration) {
+	defer pool.workers.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-pool.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pool.done:
+			return
+		case <-ticker.C:
+		}
+
+		for i := range pool.pools {
+			pool.reapServer(i)
+			pool.topUp(ctx, i)
+		}
+	}
+}
+
+// reapServer close the expired idle connections of the i-th server
+func (pool *ConnectionPool) reapServer(i int) {
+	now := time.Now()
+
+	for n := len(pool.pools[i]); n > 0; n-- {
+		select {
+		case conn := <-pool.pools[i]:
+			if pool.expired(conn, now) {
+				pool.closeConn(conn)
+			} else {
+				pool.putIdle(i, conn)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// topUp dial new connections until the i-th server has MinIdleConns idle connections,
+// it stops at the first failure or when MaxOpenConns are open
+func (pool *ConnectionPool) topUp(ctx context.Context, i int) {
+	for len(pool.pools[i]) < pool.config.MinIdleConns && len(pool.pools[i]) < cap(pool.pools[i]) {
+		if slots := pool.slots[i]; slots != nil {
+			select {
+			case slots <- struct{}{}:
+			default:
+				return
+			}
+		}
+
+		conn, err := pool.dial(ctx, i)
+		if err != nil {
+			return
+		}
+
+		pool.putIdle(i, conn)
+	}
+}
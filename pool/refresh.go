@@ -0,0 +1,54 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// refresh add back the servers that are down and can be connected again, every interval until the pool is closed
+func (pool *ConnectionPool) refresh(interval time.Duration) {
+	defer pool.workers.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-pool.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pool.done:
+			return
+		case <-ticker.C:
+		}
+
+		pool.selector.Each(func(i int, server string, up bool) error {
+			if !up {
+				pool.probe(ctx, i)
+			}
+			return ctx.Err()
+		})
+	}
+}
+
+// probe dial the i-th server and mark it up if the connection is alive
+func (pool *ConnectionPool) probe(ctx context.Context, i int) {
+	conn, err := pool.factory.NewTcpConnectContext(ctx, pool.config.Addr(i), i)
+	if err != nil {
+		return
+	}
+
+	if conn.Alive() {
+		pool.selector.MarkUp(i)
+	}
+
+	conn.Close()
+}
@@ -0,0 +1,79 @@
+package pool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ServerStats snapshot of the statistics of the connections of a server.
+type ServerStats struct {
+	Open            int           //open connections, idle and in use
+	Idle            int           //connections waiting in the pool
+	InUse           int           //connections handed out and not released yet
+	Dials           int64         //connections dialed
+	DialFailures    int64         //dials that failed
+	ClosedOnError   int64         //connections discarded after an error or closed by a failed health check
+	DiscardedFull   int64         //released connections closed because the idle pool was full
+	WaitCount       int64         //Get calls that waited because MaxOpenConns connections were in use
+	WaitDuration    time.Duration //total time waited
+	AcquireCount    int64         //connections handed out
+	AcquireDuration time.Duration //total time spent getting the connections handed out, AcquireDuration / AcquireCount is the mean latency
+}
+
+type serverStats struct {
+	open            int64
+	inUse           int64
+	dials           int64
+	dialFailures    int64
+	closedOnError   int64
+	discardedFull   int64
+	waitCount       int64
+	waitDuration    int64
+	acquireCount    int64
+	acquireDuration int64
+}
+
+func (s *serverStats) wait(d time.Duration) {
+	atomic.AddInt64(&s.waitCount, 1)
+	atomic.AddInt64(&s.waitDuration, int64(d))
+}
+
+func (s *serverStats) acquire(d time.Duration) {
+	atomic.AddInt64(&s.acquireCount, 1)
+	atomic.AddInt64(&s.acquireDuration, int64(d))
+}
+
+func (s *serverStats) dial(err error) {
+	atomic.AddInt64(&s.dials, 1)
+
+	if err != nil {
+		atomic.AddInt64(&s.dialFailures, 1)
+	} else {
+		atomic.AddInt64(&s.open, 1)
+	}
+}
+
+// Stats return a snapshot of the statistics of every server, keyed by server address
+func (pool *ConnectionPool) Stats() map[string]ServerStats {
+	stats := make(map[string]ServerStats, len(pool.config.Servers))
+
+	for i := range pool.config.Servers {
+		s := pool.stats[i]
+
+		stats[pool.config.Addr(i)] = ServerStats{
+			Open:            int(atomic.LoadInt64(&s.open)),
+			Idle:            len(pool.pools[i]),
+			InUse:           int(atomic.LoadInt64(&s.inUse)),
+			Dials:           atomic.LoadInt64(&s.dials),
+			DialFailures:    atomic.LoadInt64(&s.dialFailures),
+			ClosedOnError:   atomic.LoadInt64(&s.closedOnError),
+			DiscardedFull:   atomic.LoadInt64(&s.discardedFull),
+			WaitCount:       atomic.LoadInt64(&s.waitCount),
+			WaitDuration:    time.Duration(atomic.LoadInt64(&s.waitDuration)),
+			AcquireCount:    atomic.LoadInt64(&s.acquireCount),
+			AcquireDuration: time.Duration(atomic.LoadInt64(&s.acquireDuration)),
+		}
+	}
+
+	return stats
+}
@@ -2,38 +2,55 @@
 package selector
 
 import (
-	"container/list"
 	"fmt"
-	"hash/crc32"
+	"sort"
 	"sync"
-	"time"
+	"sync/atomic"
 
+	"github.com/ningjh/memcached/common"
 	"github.com/ningjh/memcached/config"
-	"github.com/ningjh/memcached/factory"
 )
 
 // Node server virtual node
 type Node struct {
-	HashCode    uint32
+	HashCode    uint64
 	ServerIndex int
 }
 
-// Consistent consistent hashing table
+// Consistent consistent hashing table, the ring ServerSelector.
+// The keys and the virtual nodes are hashed with config.HashFunc, CRC32 by default, the points are 64-bit with a 64-bit hash.
+// With config.Ketama the ring is the one of libketama, the server choices match libketama and twemproxy for the same "host:port" names.
+// libmemcached and pylibmc drop the default port 11211 from the point names, and spymemcached names them "host/ip:port", so they pick other servers.
+// The ring is an immutable slice sorted by hash code, replaced on every change, so Get takes no lock.
 type Consistent struct {
-	config           *config.Config
-	circle           *list.List    //store virtual nodes
+	servers          []string
+	ring             atomic.Value //[]Node, the virtual nodes sorted by hash code
 	numberOfReplicas int
-	nodesStatus      []bool        //the memcached server status, enabled or crash
-	factory          *factory.ConnectionFactory
+	ketama           bool
+	hash             HashFunc
+	nodesStatus      []bool //the memcached server status, enabled or crash
 	sync.RWMutex
 }
 
 func NewConsistent(c *config.Config) *Consistent {
+	numberOfReplicas := c.NumberOfReplicas
+	if c.Ketama {
+		numberOfReplicas = DefaultKetamaPoints
+		if c.KetamaPoints > 0 {
+			numberOfReplicas = c.KetamaPoints
+		}
+	}
+
+	hash := c.HashFunc
+	if hash == nil {
+		hash = CRC32
+	}
+
 	return &Consistent{
-		config:           c,
-		circle:           list.New(),
-		numberOfReplicas: c.NumberOfReplicas,
-		factory:          factory.NewConnectionFactory(c),
+		servers:          c.Servers,
+		numberOfReplicas: numberOfReplicas,
+		ketama:           c.Ketama,
+		hash:             hash,
 		nodesStatus:      make([]bool, len(c.Servers)),
 	}
 }
@@ -43,52 +60,100 @@ func (c *Consistent) genKey(key string, i int) string {
 	return fmt.Sprintf("%s#%d", key, i)
 }
 
-func (c *Consistent) hashCode(key string) uint32 {
-	return crc32.ChecksumIEEE([]byte(key))
+func (c *Consistent) hashCode(key string) uint64 {
+	if c.ketama {
+		return uint64(ketamaHash(key))
+	}
+
+	return c.hash(key)
+}
+
+// points return the hash codes of the virtual nodes of a server
+// a server of weight w has w times NumberOfReplicas virtual nodes, on libketama its share of the points is w over the total weight
+func (c *Consistent) points(key string) []uint64 {
+	addr, weight := parseServer(key)
+
+	if c.ketama {
+		total := 0
+		for _, server := range c.servers {
+			_, w := parseServer(server)
+			total += w
+		}
+
+		return ketamaPoints(addr, ketamaCount(weight, total, len(c.servers), c.numberOfReplicas))
+	}
+
+	points := make([]uint64, c.numberOfReplicas*weight)
+	for i := range points {
+		points[i] = c.hashCode(c.genKey(addr, i))
+	}
+
+	return points
+}
+
+// after report whether a key hashed to hashCode belongs to a node, the first node at or after it on libketama, strictly after it otherwise
+func (c *Consistent) after(hashCode uint64, n *Node) bool {
+	if c.ketama {
+		return hashCode <= n.HashCode
+	}
+
+	return hashCode < n.HashCode
 }
 
 func (c *Consistent) getServerIndex(key string) int {
-	for i, v := range c.config.Servers {
+	for i, v := range c.servers {
 		if v == key {
 			return i
 		}
 	}
 
-	return 0
+	return -1
+}
+
+// nodes return the virtual nodes of the ring, sorted by hash code, they must not be modified
+func (c *Consistent) nodes() []Node {
+	nodes, _ := c.ring.Load().([]Node)
+	return nodes
+}
+
+// sortNodes sort nodes by hash code, nodes with the same hash code stay in the order they were added
+func sortNodes(nodes []Node) {
+	sort.SliceStable(nodes, func(a, b int) bool {
+		return nodes[a].HashCode < nodes[b].HashCode
+	})
 }
 
-// add store a virtual node
+// add store the virtual nodes of a server in a copy of the ring
 func (c *Consistent) add(key string) {
 	serverIndex := c.getServerIndex(key)
+	if serverIndex < 0 {
+		return
+	}
+
 	c.nodesStatus[serverIndex] = true
 
-	for i := 0; i < c.numberOfReplicas; i++ {
-		node := &Node{
-			HashCode:    c.hashCode(c.genKey(key, i)),
-			ServerIndex: serverIndex,
-		}
+	points := c.points(key)
+	added := make([]Node, len(points))
+	for i, hashCode := range points {
+		added[i] = Node{HashCode: hashCode, ServerIndex: serverIndex}
+	}
+	sortNodes(added)
 
-		if e := c.circle.Back(); e == nil {
-			//if the circle is empty, insert the node at the front of the circle.
-			c.circle.PushFront(node)
+	// merge the sorted nodes, the nodes already on the ring come first on equal hash codes
+	old := c.nodes()
+	nodes := make([]Node, 0, len(old)+len(added))
+
+	for len(old) > 0 && len(added) > 0 {
+		if added[0].HashCode < old[0].HashCode {
+			nodes, added = append(nodes, added[0]), added[1:]
 		} else {
-			if n, ok := e.Value.(*Node); ok {
-				if node.HashCode > n.HashCode {
-					//if the node is the maximal, insert the node at the back of the circle.
-					c.circle.PushBack(node)
-				} else {
-					for e = c.circle.Front(); e != nil; e = e.Next() {
-						if n, ok = e.Value.(*Node); ok {
-							if node.HashCode < n.HashCode {
-								c.circle.InsertBefore(node, e)
-								break
-							}
-						}
-					}
-				}
-			}
+			nodes, old = append(nodes, old[0]), old[1:]
 		}
 	}
+
+	nodes = append(append(nodes, old...), added...)
+
+	c.ring.Store(nodes)
 }
 
 // Add store a virtual node
@@ -104,74 +169,134 @@ func (c *Consistent) Remove(key string) {
 	c.Lock()
 	defer c.Unlock()
 
-	for i := 0; i < c.numberOfReplicas; i++ {
-		hashCode := c.hashCode(c.genKey(key, i))
-
-		for e := c.circle.Front(); e != nil; e = e.Next() {
-			if n, ok := e.Value.(*Node); ok {
-				if n.HashCode == hashCode {
-					c.circle.Remove(e)
-					c.nodesStatus[n.ServerIndex] = false
-					break
-				}
-			}
+	c.remove(key)
+}
+
+// remove the virtual nodes of a server from a copy of the ring
+func (c *Consistent) remove(key string) {
+	serverIndex := c.getServerIndex(key)
+	if serverIndex < 0 {
+		return
+	}
+
+	c.nodesStatus[serverIndex] = false
+
+	old := c.nodes()
+	nodes := make([]Node, 0, len(old))
+
+	for _, n := range old {
+		if n.ServerIndex != serverIndex {
+			nodes = append(nodes, n)
 		}
 	}
+
+	c.ring.Store(nodes)
 }
 
-// Get retrieval a virtual node
+// Get retrieval a virtual node, with a binary search of the ring
 func (c *Consistent) Get(key string) (server int, err error) {
-	c.RLock()
-	defer c.RUnlock()
+	nodes := c.nodes()
+	if len(nodes) == 0 {
+		return -1, common.ErrNoAvailableServer
+	}
 
-	server = -1
 	hashCode := c.hashCode(key)
 
-	for e := c.circle.Front(); e != nil; e = e.Next() {
-		if n, ok := e.Value.(*Node); ok {
-			if hashCode < n.HashCode {
-				server = n.ServerIndex
-				break
-			}
+	lo, hi := 0, len(nodes)
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		if c.after(hashCode, &nodes[m]) {
+			hi = m
+		} else {
+			lo = m + 1
 		}
 	}
 
-	if server == -1 {
-		if e := c.circle.Front(); e != nil {
-			if n, ok := e.Value.(*Node); ok {
-				server = n.ServerIndex
-			}
+	// past the last node, the ring wraps around to the first one
+	if lo == len(nodes) {
+		lo = 0
+	}
+
+	return nodes[lo].ServerIndex, nil
+}
+
+// SetServers replace the servers of the ring, every server is up
+func (c *Consistent) SetServers(servers ...string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, server := range servers {
+		if _, _, err := config.ParseServer(server); err != nil {
+			return err
 		}
 	}
 
-	if server == -1 {
-		err = fmt.Errorf("Memcached : could not found a server")
+	c.servers = append([]string(nil), servers...)
+	c.nodesStatus = make([]bool, len(servers))
+
+	var nodes []Node
+	for i, server := range c.servers {
+		c.nodesStatus[i] = true
+
+		for _, hashCode := range c.points(server) {
+			nodes = append(nodes, Node{HashCode: hashCode, ServerIndex: i})
+		}
 	}
 
-	return
-}
+	sortNodes(nodes)
+	c.ring.Store(nodes)
 
-// RefreshTicker the background task regularly. 
-// Add a memcached server into hash table when it has recovered from a panic
-func (c *Consistent) RefreshTicker() {
-	ticker := time.NewTicker(time.Second * time.Duration(c.config.RefreshHashIntervalInSecond))
+	return nil
+}
 
-	go func() {
-		for _ = range ticker.C {
-			c.Lock()
+// PickServer return the server of key, the same as Get
+func (c *Consistent) PickServer(key string) (int, error) {
+	return c.Get(key)
+}
 
-			for i, v := range c.config.Servers {
-				if !c.nodesStatus[i] {
-					if conn, err := c.factory.NewTcpConnect(v, i); err == nil {
-						if conn.Connected() {
-							c.add(v)
-						}
-						conn.Close()
-					}
-				}
-			}
+// Each call fn for every server in order until fn returns an error
+func (c *Consistent) Each(fn func(i int, server string, up bool) error) error {
+	c.RLock()
+	servers := c.servers
+	up := append([]bool(nil), c.nodesStatus...)
+	c.RUnlock()
 
-			c.Unlock()
+	for i, server := range servers {
+		if err := fn(i, server, up[i]); err != nil {
+			return err
 		}
-	}()
+	}
+
+	return nil
+}
+
+// MarkDown remove the virtual nodes of the i-th server
+func (c *Consistent) MarkDown(i int) {
+	c.Lock()
+	defer c.Unlock()
+
+	if i >= 0 && i < len(c.servers) && c.nodesStatus[i] {
+		c.remove(c.servers[i])
+	}
+}
+
+// MarkUp add back the virtual nodes of the i-th server
+func (c *Consistent) MarkUp(i int) {
+	c.Lock()
+	defer c.Unlock()
+
+	if i >= 0 && i < len(c.servers) && !c.nodesStatus[i] {
+		c.add(c.servers[i])
+	}
+}
+
+// RefreshTicker does nothing, the pool adds back the servers that have recovered every RefreshHashIntervalInSecond.
+//
+// Deprecated: the refresh is done by the pool.
+func (c *Consistent) RefreshTicker() {}
+
+// Close release the ring, it is called by pool.Close.
+// The ring holds no goroutine or connection since the refresh moved to the pool, so it has nothing to stop.
+func (c *Consistent) Close() error {
+	return nil
 }
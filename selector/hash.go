@@ -0,0 +1,230 @@
+package selector
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"hash/crc32"
+	"math/bits"
+
+	"github.com/ningjh/memcached/config"
+)
+
+// HashFunc hash the keys and the virtual nodes of the ring, see config.HashFunc.
+// The 32-bit functions give points in the low 32 bits.
+type HashFunc = config.HashFunc
+
+var (
+	_ HashFunc = CRC32
+	_ HashFunc = FNV1a32
+	_ HashFunc = FNV1a64
+	_ HashFunc = MD5
+	_ HashFunc = Murmur3
+	_ HashFunc = XXHash64
+)
+
+// CRC32 the IEEE CRC32 of key, the default of the ring
+func CRC32(key string) uint64 {
+	return uint64(crc32.ChecksumIEEE([]byte(key)))
+}
+
+// FNV1a32 the 32-bit FNV-1a of key
+func FNV1a32(key string) uint64 {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+
+	return uint64(h)
+}
+
+// FNV1a64 the 64-bit FNV-1a of key
+func FNV1a64(key string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+
+	return h
+}
+
+// MD5 the first 8 bytes of the MD5 digest of key, little endian
+func MD5(key string) uint64 {
+	digest := md5.Sum([]byte(key))
+
+	return binary.LittleEndian.Uint64(digest[:8])
+}
+
+// Murmur3 the first 64 bits of the 128-bit MurmurHash3 (x64) of key, seed 0
+func Murmur3(key string) uint64 {
+	const (
+		c1 = 0x87c37b91114253d5
+		c2 = 0x4cf5ad432745937f
+	)
+
+	var h1, h2 uint64
+	n := len(key)
+
+	b := key
+	for ; len(b) >= 16; b = b[16:] {
+		k1 := le64(b)
+		k2 := le64(b[8:])
+
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	// the tail, up to 15 bytes
+	var k1, k2 uint64
+	for i := len(b) - 1; i >= 8; i-- {
+		k2 ^= uint64(b[i]) << (uint(i-8) * 8)
+	}
+	if len(b) > 8 {
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+	}
+
+	t := len(b)
+	if t > 8 {
+		t = 8
+	}
+	for i := t - 1; i >= 0; i-- {
+		k1 ^= uint64(b[i]) << (uint(i) * 8)
+	}
+	if len(b) > 0 {
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+	}
+
+	h1 ^= uint64(n)
+	h2 ^= uint64(n)
+
+	h1 += h2
+	h2 += h1
+
+	h1 = fmix64(h1)
+	h2 = fmix64(h2)
+
+	h1 += h2
+
+	return h1
+}
+
+// fmix64 the finalizer of MurmurHash3, it spreads close inputs over the 64 bits
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
+
+const (
+	prime64_1 = 11400714785074694791
+	prime64_2 = 14029467366897019727
+	prime64_3 = 1609587929392839161
+	prime64_4 = 9650029242287828579
+	prime64_5 = 2870177450012600261
+)
+
+// XXHash64 the 64-bit xxHash (XXH64) of key, seed 0
+func XXHash64(key string) uint64 {
+	n := len(key)
+	b := key
+
+	var h uint64
+
+	if n >= 32 {
+		v1 := uint64(prime64_1)
+		v1 += prime64_2
+		v2 := uint64(prime64_2)
+		v3 := uint64(0)
+		v4 := uint64(0)
+		v4 -= prime64_1
+
+		for ; len(b) >= 32; b = b[32:] {
+			v1 = xxRound(v1, le64(b))
+			v2 = xxRound(v2, le64(b[8:]))
+			v3 = xxRound(v3, le64(b[16:]))
+			v4 = xxRound(v4, le64(b[24:]))
+		}
+
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMerge(h, v1)
+		h = xxMerge(h, v2)
+		h = xxMerge(h, v3)
+		h = xxMerge(h, v4)
+	} else {
+		h = prime64_5
+	}
+
+	h += uint64(n)
+
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxRound(0, le64(b))
+		h = bits.RotateLeft64(h, 27)*prime64_1 + prime64_4
+	}
+
+	if len(b) >= 4 {
+		h ^= uint64(le32(b)) * prime64_1
+		h = bits.RotateLeft64(h, 23)*prime64_2 + prime64_3
+		b = b[4:]
+	}
+
+	for ; len(b) > 0; b = b[1:] {
+		h ^= uint64(b[0]) * prime64_5
+		h = bits.RotateLeft64(h, 11) * prime64_1
+	}
+
+	h ^= h >> 33
+	h *= prime64_2
+	h ^= h >> 29
+	h *= prime64_3
+	h ^= h >> 32
+
+	return h
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * prime64_2
+	acc = bits.RotateLeft64(acc, 31)
+	acc *= prime64_1
+	return acc
+}
+
+func xxMerge(acc, val uint64) uint64 {
+	val = xxRound(0, val)
+	acc ^= val
+	acc = acc*prime64_1 + prime64_4
+	return acc
+}
+
+func le64(b string) uint64 {
+	return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24 |
+		uint64(b[4])<<32 | uint64(b[5])<<40 | uint64(b[6])<<48 | uint64(b[7])<<56
+}
+
+func le32(b string) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
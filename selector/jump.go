@@ -0,0 +1,79 @@
+package selector
+
+import (
+	"github.com/ningjh/memcached/common"
+)
+
+// Jump pick the server of a key with the jump consistent hash of Lamping and Veach over the FNV-1a 64 of the key,
+// a server of weight w owns w buckets.
+// The key of a server that is down is jumped again with a derived hash until a server that is up is found,
+// so only the keys of that server move.
+type Jump struct {
+	serverList
+	buckets []int //the server of each bucket
+}
+
+func NewJump() *Jump {
+	return &Jump{}
+}
+
+// SetServers replace the servers, every server is up.
+// The buckets are built first and swapped in with the servers, so PickServer never sees them apart.
+func (j *Jump) SetServers(servers ...string) error {
+	addrs, weights, err := parseServers(servers)
+	if err != nil {
+		return err
+	}
+
+	buckets := make([]int, 0, len(servers))
+	for i, w := range weights {
+		for k := 0; k < w; k++ {
+			buckets = append(buckets, i)
+		}
+	}
+
+	j.Lock()
+	defer j.Unlock()
+
+	j.set(servers, addrs, weights)
+	j.buckets = buckets
+
+	return nil
+}
+
+// jumpHash the bucket of key among n buckets
+func jumpHash(key uint64, n int) int {
+	var b, j int64 = -1, 0
+
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+
+	return int(b)
+}
+
+// PickServer return the server of key
+func (j *Jump) PickServer(key string) (int, error) {
+	j.RLock()
+	defer j.RUnlock()
+
+	hash := FNV1a64(key)
+
+	n := len(j.buckets)
+	if n == 0 {
+		return -1, common.ErrNoAvailableServer
+	}
+
+	// the chance to miss every server that is up fades quickly, give up as the ring does when all are down
+	for attempt := 0; attempt < 64*n; attempt++ {
+		if i := j.buckets[jumpHash(hash, n)]; j.up[i] {
+			return i, nil
+		}
+
+		hash = hash*6364136223846793005 + 1442695040888963407
+	}
+
+	return -1, common.ErrNoAvailableServer
+}
@@ -0,0 +1,50 @@
+package selector
+
+import (
+	"crypto/md5"
+	"math"
+	"strconv"
+)
+
+// DefaultKetamaPoints points of each server on a libketama ring, 40 MD5 digests of 4 points
+const DefaultKetamaPoints = 160
+
+// ketamaHash the point of key on a libketama ring, the first 4 bytes of its MD5 digest, little endian
+func ketamaHash(key string) uint32 {
+	digest := md5.Sum([]byte(key))
+
+	return ketamaPoint(digest[:], 0)
+}
+
+// ketamaPoint the h-th point (0 to 3) of an MD5 digest
+func ketamaPoint(digest []byte, h int) uint32 {
+	return uint32(digest[3+h*4])<<24 | uint32(digest[2+h*4])<<16 | uint32(digest[1+h*4])<<8 | uint32(digest[h*4])
+}
+
+// ketamaPoints the points of server on a libketama ring, each MD5 digest of "<server>-<k>" gives 4 points
+func ketamaPoints(server string, n int) []uint64 {
+	points := make([]uint64, 0, n+3)
+
+	for k := 0; len(points) < n; k++ {
+		digest := md5.Sum([]byte(server + "-" + strconv.Itoa(k)))
+
+		for h := 0; h < 4; h++ {
+			points = append(points, uint64(ketamaPoint(digest[:], h)))
+		}
+	}
+
+	return points[:n]
+}
+
+// ketamaCount the number of points of a server of weight among n servers of total weight,
+// as libmemcached and twemproxy compute it in single precision, a multiple of 4
+func ketamaCount(weight, total, n, points int) int {
+	if weight*n == total {
+		return points
+	}
+
+	pct := float32(weight) / float32(total)
+	f := float32(float32(pct*float32(points))/4) * float32(n)
+
+	return int(math.Floor(float64(float32(float64(f)+0.0000000001)))) * 4
+}
@@ -0,0 +1,49 @@
+package selector
+
+import (
+	"hash/crc32"
+
+	"github.com/ningjh/memcached/common"
+)
+
+// Modulo pick the server of a key with the CRC32 of the key modulo the total weight of the servers that are up,
+// a server of weight w takes w consecutive slots.
+// With every server up and no weight the choices match gomemcache's ServerList.
+// Marking a server down or up moves most of the keys.
+type Modulo struct {
+	serverList
+}
+
+func NewModulo() *Modulo {
+	return &Modulo{}
+}
+
+// PickServer return the server of key
+func (m *Modulo) PickServer(key string) (int, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	n := 0
+	for i, up := range m.up {
+		if up {
+			n += m.weights[i]
+		}
+	}
+
+	if n == 0 {
+		return -1, common.ErrNoAvailableServer
+	}
+
+	k := int(crc32.ChecksumIEEE([]byte(key)) % uint32(n))
+
+	for i, up := range m.up {
+		if up {
+			if k < m.weights[i] {
+				return i, nil
+			}
+			k -= m.weights[i]
+		}
+	}
+
+	return -1, common.ErrNoAvailableServer
+}
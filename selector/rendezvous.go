@@ -0,0 +1,48 @@
+package selector
+
+import (
+	"math"
+
+	"github.com/ningjh/memcached/common"
+)
+
+// Rendezvous pick the server of a key with the highest random weight (HRW):
+// the server that is up with the largest score, -w / ln(h) where w is the weight of the server
+// and h the FNV-1a 64 hashes of the server address and of the key mixed by the MurmurHash3 finalizer, scaled to (0, 1).
+// Only the keys of a server that is marked down move.
+type Rendezvous struct {
+	serverList
+}
+
+func NewRendezvous() *Rendezvous {
+	return &Rendezvous{}
+}
+
+// PickServer return the server of key
+func (r *Rendezvous) PickServer(key string) (int, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	server := -1
+	var max float64
+
+	k := FNV1a64(key)
+
+	for i, s := range r.addrs {
+		if !r.up[i] {
+			continue
+		}
+
+		h := (float64(fmix64(FNV1a64(s)^k)>>11) + 0.5) / (1 << 53)
+
+		if score := -float64(r.weights[i]) / math.Log(h); server == -1 || score > max {
+			server, max = i, score
+		}
+	}
+
+	if server == -1 {
+		return -1, common.ErrNoAvailableServer
+	}
+
+	return server, nil
+}
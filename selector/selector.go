@@ -0,0 +1,115 @@
+package selector
+
+import (
+	"io"
+	"sync"
+
+	"github.com/ningjh/memcached/config"
+)
+
+// ServerSelector choose the server of a key, see config.ServerSelector.
+type ServerSelector = config.ServerSelector
+
+var (
+	_ ServerSelector = (*Consistent)(nil)
+	_ ServerSelector = (*Modulo)(nil)
+	_ ServerSelector = (*Jump)(nil)
+	_ ServerSelector = (*Rendezvous)(nil)
+
+	_ io.Closer = (*Consistent)(nil)
+)
+
+// parseServer the address and the weight of a server, a malformed server has weight 1
+func parseServer(server string) (string, int) {
+	addr, weight, err := config.ParseServer(server)
+	if err != nil {
+		return server, 1
+	}
+
+	return addr, weight
+}
+
+// serverList the servers of a selector, their weight and whether they are up
+type serverList struct {
+	servers []string
+	addrs   []string
+	weights []int
+	up      []bool
+	sync.RWMutex
+}
+
+// SetServers replace the servers, every server is up
+func (l *serverList) SetServers(servers ...string) error {
+	addrs, weights, err := parseServers(servers)
+	if err != nil {
+		return err
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	l.set(servers, addrs, weights)
+
+	return nil
+}
+
+// parseServers the addresses and the weights of servers
+func parseServers(servers []string) ([]string, []int, error) {
+	addrs := make([]string, len(servers))
+	weights := make([]int, len(servers))
+
+	for i, server := range servers {
+		var err error
+		if addrs[i], weights[i], err = config.ParseServer(server); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return addrs, weights, nil
+}
+
+// set replace the servers with the parsed ones, every server is up, the lock must be held
+func (l *serverList) set(servers, addrs []string, weights []int) {
+	l.servers = append([]string(nil), servers...)
+	l.addrs = addrs
+	l.weights = weights
+	l.up = make([]bool, len(servers))
+	for i := range l.up {
+		l.up[i] = true
+	}
+}
+
+// Each call fn for every server in order until fn returns an error
+func (l *serverList) Each(fn func(i int, server string, up bool) error) error {
+	l.RLock()
+	servers := l.servers
+	up := append([]bool(nil), l.up...)
+	l.RUnlock()
+
+	for i, server := range servers {
+		if err := fn(i, server, up[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarkDown stop choosing the i-th server
+func (l *serverList) MarkDown(i int) {
+	l.mark(i, false)
+}
+
+// MarkUp choose the i-th server again
+func (l *serverList) MarkUp(i int) {
+	l.mark(i, true)
+}
+
+func (l *serverList) mark(i int, up bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	if i >= 0 && i < len(l.up) {
+		l.up[i] = up
+	}
+}
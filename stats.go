@@ -0,0 +1,418 @@
+package memcached
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ningjh/memcached/pool"
+)
+
+// PoolStats statistics of the connection pool of a server, see Client.PoolStats.
+type PoolStats = pool.ServerStats
+
+// ServerStats typed view of the general statistics ('stats') of a server.
+type ServerStats struct {
+	Pid              uint64
+	Uptime           uint64 //seconds
+	Version          string
+	Threads          uint64
+	CurrItems        uint64
+	TotalItems       uint64
+	Bytes            uint64 //bytes used to store items
+	LimitMaxbytes    uint64 //bytes the server is allowed to use
+	CurrConnections  uint64
+	TotalConnections uint64
+	CmdGet           uint64
+	CmdSet           uint64
+	GetHits          uint64
+	GetMisses        uint64
+	Evictions        uint64
+	BytesRead        uint64
+	BytesWritten     uint64
+}
+
+// SlabClass statistics of a slab class, merged from 'stats slabs' and 'stats items'.
+type SlabClass struct {
+	ChunkSize      uint64 //bytes
+	ChunksPerPage  uint64
+	TotalPages     uint64
+	TotalChunks    uint64
+	UsedChunks     uint64
+	FreeChunks     uint64
+	MemRequested   uint64
+	GetHits        uint64
+	CmdSet         uint64
+	Number         uint64 //items stored in the class
+	Age            uint64 //seconds, age of the oldest item
+	Evicted        uint64
+	EvictedNonzero uint64
+	EvictedTime    uint64 //seconds since the last access of the last evicted item
+	OutOfMemory    uint64
+	Reclaimed      uint64
+}
+
+// Settings typed view of 'stats settings'.
+type Settings struct {
+	MaxBytes     uint64
+	MaxConns     uint64
+	TCPPort      uint64
+	NumThreads   uint64
+	ChunkSize    uint64
+	ItemSizeMax  uint64
+	GrowthFactor float64
+	Evictions    bool
+	Raw          map[string]string //every setting, including the ones without a field
+}
+
+// ConnStats a connection reported by 'stats conns'.
+type ConnStats struct {
+	FD               int
+	Addr             string
+	ListenAddr       string
+	State            string
+	SecsSinceLastCmd uint64
+}
+
+// ServerSnapshot the typed statistics of one server.
+type ServerSnapshot struct {
+	General     ServerStats
+	ActiveSlabs uint64
+	TotalMalloc uint64
+	Slabs       map[int]*SlabClass //keyed by slab class id
+	Settings    Settings
+	Conns       []ConnStats
+}
+
+// ClusterSnapshot the typed statistics of every server, keyed by server address.
+type ClusterSnapshot struct {
+	Time    time.Time
+	Servers map[string]*ServerSnapshot
+}
+
+// ClusterRates cluster-wide figures computed from two snapshots by Rates.
+type ClusterRates struct {
+	Interval          time.Duration
+	HitRatio          float64 //get hits / gets during the interval, 0 without gets
+	EvictionRate      float64 //evictions per second during the interval
+	MemoryUtilisation float64 //bytes / limit_maxbytes at the end of the interval
+}
+
+// Snapshot collect 'stats', 'stats slabs', 'stats items', 'stats settings' and 'stats conns' from every server.
+// If some servers fail, the snapshot holds the others and the error of the first failed server is returned.
+func Snapshot(client Client) (*ClusterSnapshot, error) {
+	return SnapshotContext(context.Background(), client)
+}
+
+// SnapshotContext is like Snapshot, ctx bounds the requests
+func SnapshotContext(ctx context.Context, client Client) (*ClusterSnapshot, error) {
+	var firstErr error
+
+	snapshot := &ClusterSnapshot{
+		Time:    time.Now(),
+		Servers: make(map[string]*ServerSnapshot),
+	}
+
+	groups := []string{"", "slabs", "items", "settings", "conns"}
+	raw := make([]map[string]map[string]string, len(groups))
+
+	for i, group := range groups {
+		var err error
+
+		if group == "" {
+			raw[i], err = client.StatsContext(ctx)
+		} else {
+			raw[i], err = client.StatsContext(ctx, group)
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	// only the servers that answered every group
+	for server := range raw[0] {
+		complete := true
+		for _, r := range raw[1:] {
+			if _, ok := r[server]; !ok {
+				complete = false
+			}
+		}
+
+		if complete {
+			snapshot.Servers[server] = parseServerSnapshot(raw[0][server], raw[1][server], raw[2][server], raw[3][server], raw[4][server])
+		}
+	}
+
+	return snapshot, firstErr
+}
+
+// Total sum the general statistics of every server, Pid, Uptime and Version are left empty, see TotalSlabs for the slab classes.
+func (s *ClusterSnapshot) Total() (total ServerStats) {
+	for _, server := range s.Servers {
+		g := server.General
+
+		total.Threads += g.Threads
+		total.CurrItems += g.CurrItems
+		total.TotalItems += g.TotalItems
+		total.Bytes += g.Bytes
+		total.LimitMaxbytes += g.LimitMaxbytes
+		total.CurrConnections += g.CurrConnections
+		total.TotalConnections += g.TotalConnections
+		total.CmdGet += g.CmdGet
+		total.CmdSet += g.CmdSet
+		total.GetHits += g.GetHits
+		total.GetMisses += g.GetMisses
+		total.Evictions += g.Evictions
+		total.BytesRead += g.BytesRead
+		total.BytesWritten += g.BytesWritten
+	}
+
+	return
+}
+
+// TotalSlabs merge the slab classes of every server by class id.
+// The counters are summed, ChunkSize and ChunksPerPage are the largest reported, so the same with the same settings,
+// Age is the oldest item of the cluster and EvictedTime the most recent eviction.
+func (s *ClusterSnapshot) TotalSlabs() map[int]*SlabClass {
+	total := make(map[int]*SlabClass)
+
+	for _, server := range s.Servers {
+		for id, c := range server.Slabs {
+			t, ok := total[id]
+			if !ok {
+				t = new(SlabClass)
+				total[id] = t
+			}
+
+			t.ChunkSize = max(t.ChunkSize, c.ChunkSize)
+			t.ChunksPerPage = max(t.ChunksPerPage, c.ChunksPerPage)
+			t.TotalPages += c.TotalPages
+			t.TotalChunks += c.TotalChunks
+			t.UsedChunks += c.UsedChunks
+			t.FreeChunks += c.FreeChunks
+			t.MemRequested += c.MemRequested
+			t.GetHits += c.GetHits
+			t.CmdSet += c.CmdSet
+			t.Number += c.Number
+			t.Age = max(t.Age, c.Age)
+			if c.Evicted > 0 && (t.Evicted == 0 || c.EvictedTime < t.EvictedTime) {
+				t.EvictedTime = c.EvictedTime
+			}
+			t.Evicted += c.Evicted
+			t.EvictedNonzero += c.EvictedNonzero
+			t.OutOfMemory += c.OutOfMemory
+			t.Reclaimed += c.Reclaimed
+		}
+	}
+
+	return total
+}
+
+// Rates compute the cluster-wide hit ratio, eviction rate and memory utilisation between two snapshots.
+// Only the servers present in both snapshots are counted, a restarted server counts from zero.
+func Rates(prev, cur *ClusterSnapshot) (rates ClusterRates) {
+	var hits, misses, evictions, bytes, maxbytes uint64
+
+	rates.Interval = cur.Time.Sub(prev.Time)
+
+	for server, c := range cur.Servers {
+		p, ok := prev.Servers[server]
+		if !ok {
+			continue
+		}
+
+		hits += delta(p.General.GetHits, c.General.GetHits)
+		misses += delta(p.General.GetMisses, c.General.GetMisses)
+		evictions += delta(p.General.Evictions, c.General.Evictions)
+		bytes += c.General.Bytes
+		maxbytes += c.General.LimitMaxbytes
+	}
+
+	if hits+misses > 0 {
+		rates.HitRatio = float64(hits) / float64(hits+misses)
+	}
+
+	if rates.Interval > 0 {
+		rates.EvictionRate = float64(evictions) / rates.Interval.Seconds()
+	}
+
+	if maxbytes > 0 {
+		rates.MemoryUtilisation = float64(bytes) / float64(maxbytes)
+	}
+
+	return
+}
+
+// delta of a counter, a counter lower than before means the server restarted
+func delta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+
+	return cur - prev
+}
+
+func parseServerSnapshot(general, slabs, items, settings, conns map[string]string) *ServerSnapshot {
+	s := &ServerSnapshot{
+		General: ServerStats{
+			Pid:              statUint(general, "pid"),
+			Uptime:           statUint(general, "uptime"),
+			Version:          general["version"],
+			Threads:          statUint(general, "threads"),
+			CurrItems:        statUint(general, "curr_items"),
+			TotalItems:       statUint(general, "total_items"),
+			Bytes:            statUint(general, "bytes"),
+			LimitMaxbytes:    statUint(general, "limit_maxbytes"),
+			CurrConnections:  statUint(general, "curr_connections"),
+			TotalConnections: statUint(general, "total_connections"),
+			CmdGet:           statUint(general, "cmd_get"),
+			CmdSet:           statUint(general, "cmd_set"),
+			GetHits:          statUint(general, "get_hits"),
+			GetMisses:        statUint(general, "get_misses"),
+			Evictions:        statUint(general, "evictions"),
+			BytesRead:        statUint(general, "bytes_read"),
+			BytesWritten:     statUint(general, "bytes_written"),
+		},
+		ActiveSlabs: statUint(slabs, "active_slabs"),
+		TotalMalloc: statUint(slabs, "total_malloced"),
+		Slabs:       make(map[int]*SlabClass),
+		Settings: Settings{
+			MaxBytes:    statUint(settings, "maxbytes"),
+			MaxConns:    statUint(settings, "maxconns"),
+			TCPPort:     statUint(settings, "tcpport"),
+			NumThreads:  statUint(settings, "num_threads"),
+			ChunkSize:   statUint(settings, "chunk_size"),
+			ItemSizeMax: statUint(settings, "item_size_max"),
+			Evictions:   settings["evictions"] == "on",
+			Raw:         settings,
+		},
+	}
+
+	s.Settings.GrowthFactor, _ = strconv.ParseFloat(settings["growth_factor"], 64)
+
+	class := func(id int) *SlabClass {
+		c, ok := s.Slabs[id]
+		if !ok {
+			c = new(SlabClass)
+			s.Slabs[id] = c
+		}
+		return c
+	}
+
+	// '<class>:<name> <value>'
+	for k, v := range slabs {
+		params := strings.SplitN(k, ":", 2)
+		if len(params) != 2 {
+			continue
+		}
+
+		id, err := strconv.Atoi(params[0])
+		if err != nil {
+			continue
+		}
+
+		n, _ := strconv.ParseUint(v, 10, 64)
+		c := class(id)
+
+		switch params[1] {
+		case "chunk_size":
+			c.ChunkSize = n
+		case "chunks_per_page":
+			c.ChunksPerPage = n
+		case "total_pages":
+			c.TotalPages = n
+		case "total_chunks":
+			c.TotalChunks = n
+		case "used_chunks":
+			c.UsedChunks = n
+		case "free_chunks":
+			c.FreeChunks = n
+		case "mem_requested":
+			c.MemRequested = n
+		case "get_hits":
+			c.GetHits = n
+		case "cmd_set":
+			c.CmdSet = n
+		}
+	}
+
+	// 'items:<class>:<name> <value>'
+	for k, v := range items {
+		params := strings.SplitN(k, ":", 3)
+		if len(params) != 3 || params[0] != "items" {
+			continue
+		}
+
+		id, err := strconv.Atoi(params[1])
+		if err != nil {
+			continue
+		}
+
+		n, _ := strconv.ParseUint(v, 10, 64)
+		c := class(id)
+
+		switch params[2] {
+		case "number":
+			c.Number = n
+		case "age":
+			c.Age = n
+		case "evicted":
+			c.Evicted = n
+		case "evicted_nonzero":
+			c.EvictedNonzero = n
+		case "evicted_time":
+			c.EvictedTime = n
+		case "outofmemory":
+			c.OutOfMemory = n
+		case "reclaimed":
+			c.Reclaimed = n
+		}
+	}
+
+	// '<fd>:<name> <value>'
+	byFD := make(map[int]*ConnStats)
+	for k, v := range conns {
+		params := strings.SplitN(k, ":", 2)
+		if len(params) != 2 {
+			continue
+		}
+
+		fd, err := strconv.Atoi(params[0])
+		if err != nil {
+			continue
+		}
+
+		c, ok := byFD[fd]
+		if !ok {
+			c = &ConnStats{FD: fd}
+			byFD[fd] = c
+		}
+
+		switch params[1] {
+		case "addr":
+			c.Addr = v
+		case "listen_addr":
+			c.ListenAddr = v
+		case "state":
+			c.State = v
+		case "secs_since_last_cmd":
+			c.SecsSinceLastCmd, _ = strconv.ParseUint(v, 10, 64)
+		}
+	}
+
+	for _, c := range byFD {
+		s.Conns = append(s.Conns, *c)
+	}
+
+	sort.Slice(s.Conns, func(i, j int) bool { return s.Conns[i].FD < s.Conns[j].FD })
+
+	return s
+}
+
+func statUint(stats map[string]string, name string) uint64 {
+	n, _ := strconv.ParseUint(stats[name], 10, 64)
+	return n
+}
@@ -0,0 +1,44 @@
+//execute 'go test -v server_test.go'
+
+package config
+
+import (
+	"testing"
+
+	"github.com/ningjh/memcached/config"
+)
+
+func TestParseServer(t *testing.T) {
+	tests := []struct {
+		server string
+		addr   string
+		weight int
+		ok     bool
+	}{
+		{"127.0.0.1:11211", "127.0.0.1:11211", 1, true},
+		{"127.0.0.1:11211 weight=3", "127.0.0.1:11211", 3, true},
+		{"  cache1:11211   weight=10 ", "cache1:11211", 10, true},
+		{"unix:///tmp/memcached.sock weight=2", "unix:///tmp/memcached.sock", 2, true},
+		{"127.0.0.1:11211 weight=0", "", 0, false},
+		{"127.0.0.1:11211 weight=x", "", 0, false},
+		{"127.0.0.1:11211 size=3", "", 0, false},
+		{"", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		addr, weight, err := config.ParseServer(tt.server)
+
+		if (err == nil) != tt.ok || addr != tt.addr || weight != tt.weight {
+			t.Errorf("%q: got %q %d %v", tt.server, addr, weight, err)
+		}
+	}
+}
+
+func TestAddr(t *testing.T) {
+	c := config.New()
+	c.Servers = []string{"127.0.0.1:11211", "127.0.0.1:11212 weight=2"}
+
+	if c.Addr(0) != "127.0.0.1:11211" || c.Addr(1) != "127.0.0.1:11212" {
+		t.Errorf("got %q %q", c.Addr(0), c.Addr(1))
+	}
+}
@@ -0,0 +1,116 @@
+//execute 'go test -v dialer_test.go'
+
+package factory
+
+import (
+	"github.com/ningjh/memcached"
+	"github.com/ningjh/memcached/config"
+
+	"bufio"
+	"context"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serveText answer version and get on c, every key has its own name as value
+func serveText(c net.Conn) {
+	defer c.Close()
+
+	r := bufio.NewReader(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "version":
+			c.Write([]byte("VERSION 1.6.0\r\n"))
+		case "get":
+			var b strings.Builder
+			for _, key := range fields[1:] {
+				b.WriteString("VALUE " + key + " 0 " + strconv.Itoa(len(key)) + "\r\n" + key + "\r\n")
+			}
+			b.WriteString("END\r\n")
+			c.Write([]byte(b.String()))
+		default:
+			c.Write([]byte("ERROR\r\n"))
+		}
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memcached.sock")
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveText(c)
+		}
+	}()
+
+	client, err := memcached.New(&config.Config{Servers: []string{"unix://" + path}, InitConns: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if item, err := client.Get("sidecar"); err != nil || string(item.Value()) != "sidecar" {
+		t.Errorf("unexpected item %v, %v", item, err)
+	}
+}
+
+func TestCustomDialer(t *testing.T) {
+	var mu sync.Mutex
+	var dialed []string
+
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		mu.Lock()
+		dialed = append(dialed, network+" "+addr)
+		mu.Unlock()
+
+		client, server := net.Pipe()
+		go serveText(server)
+
+		return client, nil
+	}
+
+	servers := []string{"cache-a:11211", "unix:///var/run/memcached.sock"}
+
+	client, err := memcached.New(&config.Config{Servers: servers, InitConns: 1, Dialer: dialer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	items, err := client.GetArray([]string{"k1", "k2", "k3", "k4"})
+	if err != nil || len(items) != 4 || string(items["k3"].Value()) != "k3" {
+		t.Errorf("unexpected items %v, %v", items, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, d := range dialed {
+		seen[d] = true
+	}
+
+	if !seen["tcp cache-a:11211"] || !seen["unix /var/run/memcached.sock"] {
+		t.Errorf("unexpected dials %v", dialed)
+	}
+}
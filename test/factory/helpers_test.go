@@ -0,0 +1,30 @@
+//execute 'go test -v helpers_test.go'
+
+package factory
+
+import (
+	"bufio"
+	"net"
+)
+
+// serveVersion accept on l and answer every line with a version reply
+func serveVersion(l net.Listener) {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		go func(c net.Conn) {
+			defer c.Close()
+
+			r := bufio.NewReader(c)
+			for {
+				if _, err := r.ReadString('\n'); err != nil {
+					return
+				}
+				c.Write([]byte("VERSION 1.6.0\r\n"))
+			}
+		}(c)
+	}
+}
@@ -0,0 +1,174 @@
+//execute 'go test -v sasl_test.go'
+
+package factory
+
+import (
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/factory"
+	"github.com/ningjh/memcached/pool"
+	"github.com/ningjh/memcached/selector"
+
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+)
+
+// saslServer a binary server offering mechs that accepts PLAIN with user and secret.
+func saslServer(t *testing.T, mechs string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := func(opcode uint8, status uint16, value string) []byte {
+		header := make([]byte, 24)
+		header[0] = 0x81
+		header[1] = opcode
+		binary.BigEndian.PutUint16(header[6:8], status)
+		binary.BigEndian.PutUint32(header[8:12], uint32(len(value)))
+		return append(header, value...)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(c net.Conn) {
+				defer c.Close()
+
+				authenticated := false
+				for {
+					header := make([]byte, 24)
+					if _, err := io.ReadFull(c, header); err != nil {
+						return
+					}
+
+					body := make([]byte, binary.BigEndian.Uint32(header[8:12]))
+					if _, err := io.ReadFull(c, body); err != nil {
+						return
+					}
+
+					keyLen := binary.BigEndian.Uint16(header[2:4])
+
+					switch header[1] {
+					case 0x20:
+						c.Write(response(0x20, 0, mechs))
+					case 0x21:
+						if string(body[:keyLen]) == "PLAIN" && string(body[keyLen:]) == "\x00user\x00secret" {
+							authenticated = true
+							c.Write(response(0x21, 0, "Authenticated"))
+						} else {
+							c.Write(response(0x21, 0x20, "Auth failure"))
+						}
+					case 0x09: // getq of the health check, quiet
+					default:
+						if !authenticated {
+							c.Write(response(header[1], 0x20, "Auth failure"))
+						}
+					}
+				}
+			}(c)
+		}
+	}()
+
+	return l
+}
+
+func TestSASLPlain(t *testing.T) {
+	l := saslServer(t, "CRAM-MD5 PLAIN")
+	defer l.Close()
+
+	c := &config.Config{Servers: []string{l.Addr().String()}, TextOrBinary: 1, Username: "user", Password: "secret"}
+
+	conn, err := factory.NewConnectionFactory(c).NewTcpConnect(c.Servers[0], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// every connection of the pool is authenticated
+	p, err := pool.New(&config.Config{Servers: c.Servers, InitConns: 4, NumberOfReplicas: 20, RefreshHashIntervalInSecond: 10,
+		TextOrBinary: 1, Username: "user", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+}
+
+func TestSASLWrongPassword(t *testing.T) {
+	l := saslServer(t, "PLAIN")
+	defer l.Close()
+
+	c := &config.Config{Servers: []string{l.Addr().String()}, TextOrBinary: 1, Username: "user", Password: "wrong"}
+
+	_, err := factory.NewConnectionFactory(c).NewTcpConnect(c.Servers[0], 0)
+
+	var authErr *common.ErrAuthError
+	if !errors.As(err, &authErr) || !errors.Is(err, common.ErrAuthFailed) {
+		t.Fatalf("expected an auth error, got %v", err)
+	}
+
+	if authErr.Server != c.Servers[0] || authErr.Mechanism != "PLAIN" {
+		t.Errorf("unexpected auth error %+v", authErr)
+	}
+
+	c.InitConns, c.NumberOfReplicas, c.RefreshHashIntervalInSecond = 4, 20, 10
+	if _, err = pool.New(c); !errors.Is(err, common.ErrAuthFailed) {
+		t.Errorf("expected the pool to fail with ErrAuthFailed, got %v", err)
+	}
+}
+
+// a rejected login is returned by Get, the servers are not marked down one after the other
+func TestSASLWrongPasswordGet(t *testing.T) {
+	a := saslServer(t, "PLAIN")
+	defer a.Close()
+	b := saslServer(t, "PLAIN")
+	defer b.Close()
+
+	c := config.New()
+	c.Servers = []string{a.Addr().String(), b.Addr().String()}
+	c.TextOrBinary = 1
+	c.Username, c.Password = "user", "wrong"
+	c.InitConns = 0
+	c.Selector = selector.NewModulo()
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	for k := 0; k < 10; k++ {
+		key := "key" + strconv.Itoa(k)
+
+		if _, err = p.Get(context.Background(), key); !errors.Is(err, common.ErrAuthFailed) {
+			t.Fatalf("%s: expected ErrAuthFailed, got %v", key, err)
+		}
+	}
+
+	c.Selector.Each(func(i int, server string, up bool) error {
+		if !up {
+			t.Errorf("server %d was marked down", i)
+		}
+		return nil
+	})
+}
+
+func TestSASLMechanismNotOffered(t *testing.T) {
+	l := saslServer(t, "CRAM-MD5")
+	defer l.Close()
+
+	c := &config.Config{Servers: []string{l.Addr().String()}, TextOrBinary: 1, Username: "user", Password: "secret"}
+
+	if _, err := factory.NewConnectionFactory(c).NewTcpConnect(c.Servers[0], 0); !errors.Is(err, common.ErrAuthFailed) {
+		t.Errorf("expected ErrAuthFailed, got %v", err)
+	}
+}
@@ -1,8 +1,11 @@
-//execute 'go test -v tcp_factory_test.go'
+//execute 'go test -tags integration -v tcp_factory_test.go'
+
+//go:build integration
 
 package factory
 
 import (
+    "github.com/ningjh/memcached/config"
     "github.com/ningjh/memcached/factory"
     "testing"
 )
@@ -10,16 +13,16 @@ import (
 var servers []string = []string{"127.0.0.1:6060"}
 
 func TestNewTcpConnection(t *testing.T) {
-    conn, err := factory.NewTcpConnection(servers[0])
+    c := config.New()
+    c.Servers = servers
+
+    conn, err := factory.NewConnectionFactory(c).NewTcpConnect(servers[0], 0)
 
     if err != nil {
-    	t.Error(err)
+    	t.Fatal(err)
     } else {
     	t.Log(conn)
     }
 
-    err = factory.CloseTcpConnection(conn)
-    if err != nil {
-    	t.Error(err)
-    }
-}
\ No newline at end of file
+    conn.Close()
+}
@@ -0,0 +1,238 @@
+//execute 'go test -v tls_test.go helpers_test.go'
+
+package factory
+
+import (
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/factory"
+
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// issue create a certificate signed by parent (self-signed without parent)
+func issue(t *testing.T, name string, parent *tls.Certificate, template *x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.Subject = pkix.Name{CommonName: name}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signer, signerKey := template, interface{}(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+type pki struct {
+	roots  *x509.CertPool
+	server tls.Certificate
+	client tls.Certificate
+}
+
+func newPKI(t *testing.T) *pki {
+	ca := issue(t, "test ca", nil, &x509.Certificate{IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign})
+
+	p := &pki{roots: x509.NewCertPool()}
+	p.roots.AddCert(ca.Leaf)
+
+	p.server = issue(t, "memcached", &ca, &x509.Certificate{
+		DNSNames:    []string{"cache.internal"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	p.client = issue(t, "client", &ca, &x509.Certificate{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+
+	return p
+}
+
+// tlsServer a TLS listener answering version, the server names sent by the clients are put to sni
+func tlsServer(t *testing.T, p *pki, mutual bool, sni chan<- string) net.Listener {
+	c := &tls.Config{
+		Certificates: []tls.Certificate{p.server},
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			select {
+			case sni <- hello.ServerName:
+			default:
+			}
+			return nil, nil
+		},
+	}
+
+	if mutual {
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+		c.ClientCAs = p.roots
+	}
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go serveVersion(l)
+
+	return l
+}
+
+// version send version on a new connection of the factory
+func version(c *config.Config) (string, error) {
+	conn, err := factory.NewConnectionFactory(c).NewTcpConnect(c.Servers[0], 0)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if _, err = conn.Write([]byte("version\r\n")); err != nil {
+		return "", err
+	}
+
+	return conn.ReadString('\n')
+}
+
+func TestTLS(t *testing.T) {
+	p := newPKI(t)
+	sni := make(chan string, 1)
+
+	l := tlsServer(t, p, false, sni)
+	defer l.Close()
+
+	c := &config.Config{Servers: []string{l.Addr().String()}, TLS: &tls.Config{RootCAs: p.roots}}
+
+	if v, err := version(c); err != nil || v != "VERSION 1.6.0\r\n" {
+		t.Fatalf("unexpected version %q, %v", v, err)
+	}
+
+	// the host of the address is the default server name
+	if name := <-sni; name != "" && name != "127.0.0.1" {
+		t.Errorf("unexpected server name %q", name)
+	}
+
+	if c.TLS.ServerName != "" {
+		t.Error("the shared TLS config was modified")
+	}
+
+	// a certificate the client does not trust
+	c.TLS = &tls.Config{}
+	if _, err := version(c); err == nil {
+		t.Error("expected the handshake to fail")
+	}
+}
+
+func TestTLSServerOverride(t *testing.T) {
+	p := newPKI(t)
+	sni := make(chan string, 1)
+
+	l := tlsServer(t, p, false, sni)
+	defer l.Close()
+
+	plain, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	go serveVersion(plain)
+
+	c := &config.Config{
+		Servers: []string{l.Addr().String()},
+		TLS:     &tls.Config{}, // would fail, the override is used
+		ServerTLS: map[string]*tls.Config{
+			l.Addr().String():     {RootCAs: p.roots, ServerName: "cache.internal"},
+			plain.Addr().String(): nil,
+		},
+	}
+
+	if v, err := version(c); err != nil || v != "VERSION 1.6.0\r\n" {
+		t.Fatalf("unexpected version %q, %v", v, err)
+	}
+
+	if name := <-sni; name != "cache.internal" {
+		t.Errorf("expected SNI cache.internal, got %q", name)
+	}
+
+	// a nil override disables TLS for the server
+	c.Servers = []string{plain.Addr().String()}
+	if v, err := version(c); err != nil || v != "VERSION 1.6.0\r\n" {
+		t.Errorf("unexpected version %q, %v", v, err)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	p := newPKI(t)
+
+	l := tlsServer(t, p, true, nil)
+	defer l.Close()
+
+	c := &config.Config{
+		Servers:      []string{l.Addr().String()},
+		ReadTimeout:  2000,
+		WriteTimeout: 2000,
+		TLS:          &tls.Config{RootCAs: p.roots, Certificates: []tls.Certificate{p.client}},
+	}
+
+	if v, err := version(c); err != nil || v != "VERSION 1.6.0\r\n" {
+		t.Fatalf("unexpected version %q, %v", v, err)
+	}
+
+	// without a client certificate the server rejects the connection
+	c.TLS = &tls.Config{RootCAs: p.roots}
+	if _, err := version(c); err == nil {
+		t.Error("expected the server to reject a client without certificate")
+	}
+}
+
+func TestTLSHandshakeTimeout(t *testing.T) {
+	// accepts but never answers the handshake
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	c := &config.Config{Servers: []string{l.Addr().String()}, TLS: &tls.Config{}, TLSHandshakeTimeout: 100}
+
+	start := time.Now()
+	var conn *common.Conn
+	if conn, err = factory.NewConnectionFactory(c).NewTcpConnect(c.Servers[0], 0); err == nil {
+		conn.Close()
+		t.Fatal("expected the handshake to time out")
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("the handshake took %v", elapsed)
+	}
+}
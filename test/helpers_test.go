@@ -0,0 +1,184 @@
+//execute 'go test -v helpers_test.go'
+
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeServer listen on a local port and run serve for every connection accepted, the connection is closed when serve returns
+func fakeServer(t *testing.T, serve func(c net.Conn)) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(c net.Conn) {
+				defer c.Close()
+				serve(c)
+			}(c)
+		}
+	}()
+
+	return l
+}
+
+// textServer a scripted text protocol server, it answers version itself and every other command
+// with the response handle returns, nothing for an empty one.
+// The data block of a storage command (set, add, replace, append, prepend, cas, ms) is read and passed as data.
+func textServer(t *testing.T, handle func(fields []string, data []byte) string) net.Listener {
+	return fakeServer(t, func(c net.Conn) {
+		r := bufio.NewReader(c)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+
+			if fields[0] == "version" {
+				c.Write([]byte("VERSION 1.6.0\r\n"))
+				continue
+			}
+
+			var data []byte
+			if size := dataSize(fields); size >= 0 {
+				data = make([]byte, size+2)
+				if _, err := io.ReadFull(r, data); err != nil {
+					return
+				}
+				data = data[:size]
+			}
+
+			if res := handle(fields, data); res != "" {
+				c.Write([]byte(res))
+			}
+		}
+	})
+}
+
+// textTable a textServer handler answering every command with its entry of table, ERROR if it has none
+func textTable(table map[string]string) func(fields []string, data []byte) string {
+	return func(fields []string, data []byte) string {
+		if res, ok := table[fields[0]]; ok {
+			return res
+		}
+		return "ERROR\r\n"
+	}
+}
+
+// dataSize the size of the data block following a storage command, -1 for the other commands
+func dataSize(fields []string) int {
+	var i int
+	switch fields[0] {
+	case "set", "add", "replace", "append", "prepend", "cas":
+		i = 4
+	case "ms":
+		i = 2
+	default:
+		return -1
+	}
+
+	if i >= len(fields) {
+		return -1
+	}
+
+	size, err := strconv.Atoi(fields[i])
+	if err != nil || size < 0 {
+		return -1
+	}
+
+	return size
+}
+
+// binaryRequest a request read by binaryServer
+type binaryRequest struct {
+	opcode uint8
+	key    string
+	extras []byte
+	value  []byte
+	cas    uint64
+}
+
+// binaryResponse a response written by binaryServer with the opcode of the request
+type binaryResponse struct {
+	status uint16
+	key    string
+	extras []byte
+	value  []byte
+	cas    uint64
+}
+
+// binaryServer a scripted binary protocol server, it skips the getq of the health check
+// and answers every other request with the responses handle returns, nothing for none.
+func binaryServer(t *testing.T, handle func(req *binaryRequest) []binaryResponse) net.Listener {
+	return fakeServer(t, func(c net.Conn) {
+		for {
+			header := make([]byte, 24)
+			if _, err := io.ReadFull(c, header); err != nil {
+				return
+			}
+
+			body := make([]byte, binary.BigEndian.Uint32(header[8:12]))
+			if _, err := io.ReadFull(c, body); err != nil {
+				return
+			}
+
+			// getq of the health check, quiet
+			if header[1] == 0x09 {
+				continue
+			}
+
+			keyLength := int(binary.BigEndian.Uint16(header[2:4]))
+			extrasLength := int(header[4])
+
+			req := &binaryRequest{
+				opcode: header[1],
+				key:    string(body[extrasLength : extrasLength+keyLength]),
+				extras: body[:extrasLength],
+				value:  body[extrasLength+keyLength:],
+				cas:    binary.BigEndian.Uint64(header[16:24]),
+			}
+
+			var buf bytes.Buffer
+			for _, res := range handle(req) {
+				packet := make([]byte, 24)
+				packet[0] = 0x81
+				packet[1] = req.opcode
+				binary.BigEndian.PutUint16(packet[2:4], uint16(len(res.key)))
+				packet[4] = uint8(len(res.extras))
+				binary.BigEndian.PutUint16(packet[6:8], res.status)
+				binary.BigEndian.PutUint32(packet[8:12], uint32(len(res.extras)+len(res.key)+len(res.value)))
+				copy(packet[12:16], header[12:16])
+				binary.BigEndian.PutUint64(packet[16:24], res.cas)
+
+				buf.Write(packet)
+				buf.Write(res.extras)
+				buf.WriteString(res.key)
+				buf.Write(res.value)
+			}
+
+			if buf.Len() > 0 {
+				c.Write(buf.Bytes())
+			}
+		}
+	})
+}
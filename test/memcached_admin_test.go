@@ -0,0 +1,143 @@
+//execute 'go test -v memcached_admin_test.go helpers_test.go'
+
+package test
+
+import (
+	"github.com/ningjh/memcached"
+	"github.com/ningjh/memcached/config"
+
+	"errors"
+	"net"
+	"testing"
+)
+
+// textAdminServer understands the text administration commands, its stats report the port.
+func textAdminServer(t *testing.T) net.Listener {
+	var port string
+	ready := make(chan struct{})
+
+	l := textServer(t, func(fields []string, data []byte) string {
+		<-ready
+
+		switch {
+		case fields[0] == "flush_all" || fields[0] == "verbosity":
+			return "OK\r\n"
+		case fields[0] == "stats" && len(fields) == 1:
+			return "STAT pid " + port + "\r\nSTAT version 1.6.0\r\nEND\r\n"
+		case fields[0] == "stats" && fields[1] == "slabs":
+			return "STAT 1:chunk_size 96\r\nSTAT active_slabs 1\r\nEND\r\n"
+		default:
+			return "ERROR\r\n"
+		}
+	})
+
+	_, port, _ = net.SplitHostPort(l.Addr().String())
+	close(ready)
+
+	return l
+}
+
+// binaryAdminResponses the responses of the binary administration opcodes, stat is answered for the general group
+var binaryAdminResponses = map[uint8][]binaryResponse{
+	0x08: {{}},                       // flush
+	0x1b: {{}},                       // verbosity
+	0x0b: {{value: []byte("1.6.0")}}, // version
+	// stat, one packet per statistic and an empty one to end
+	0x10: {{key: "pid", value: []byte("42")}, {key: "version", value: []byte("1.6.0")}, {}},
+}
+
+// binaryAdminServer understands the binary administration opcodes.
+func binaryAdminServer(t *testing.T) net.Listener {
+	return binaryServer(t, func(req *binaryRequest) []binaryResponse {
+		if req.opcode == 0x10 && req.key != "" {
+			return []binaryResponse{{key: "1:chunk_size", value: []byte("96")}, {}}
+		}
+		return binaryAdminResponses[req.opcode]
+	})
+}
+
+func TestTextAdmin(t *testing.T) {
+	l1, l2 := textAdminServer(t), textAdminServer(t)
+	defer l1.Close()
+	defer l2.Close()
+
+	servers := []string{l1.Addr().String(), l2.Addr().String()}
+
+	client, err := memcached.New(&config.Config{Servers: servers, InitConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err = client.FlushAll(0); err != nil {
+		t.Error(err)
+	}
+
+	if err = client.Verbosity(1); err != nil {
+		t.Error(err)
+	}
+
+	versions, err := client.Version()
+	if err != nil || len(versions) != 2 || versions[servers[1]] != "1.6.0" {
+		t.Errorf("unexpected versions %v, %v", versions, err)
+	}
+
+	stats, err := client.Stats()
+	if err != nil || len(stats) != 2 {
+		t.Fatalf("unexpected stats %v, %v", stats, err)
+	}
+
+	for _, server := range servers {
+		if _, port, _ := net.SplitHostPort(server); stats[server]["pid"] != port {
+			t.Errorf("stats of %s are keyed wrongly: %v", server, stats[server])
+		}
+	}
+
+	slabs, err := client.StatsServer(servers[0], "slabs")
+	if err != nil || slabs["1:chunk_size"] != "96" {
+		t.Errorf("unexpected slabs %v, %v", slabs, err)
+	}
+
+	if _, err = client.StatsServer(servers[0], "bogus"); !errors.Is(err, memcached.ErrUnknownCommand) {
+		t.Errorf("expected ErrUnknownCommand, got %v", err)
+	}
+
+	if _, err = client.VersionServer("127.0.0.1:1"); !errors.Is(err, memcached.ErrUnknownServer) {
+		t.Errorf("expected ErrUnknownServer, got %v", err)
+	}
+}
+
+func TestBinaryAdmin(t *testing.T) {
+	l := binaryAdminServer(t)
+	defer l.Close()
+
+	server := l.Addr().String()
+
+	client, err := memcached.New(&config.Config{Servers: []string{server}, InitConns: 1, TextOrBinary: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err = client.FlushAll(10); err != nil {
+		t.Error(err)
+	}
+
+	if err = client.VerbosityServer(server, 1); err != nil {
+		t.Error(err)
+	}
+
+	if v, err := client.VersionServer(server); err != nil || v != "1.6.0" {
+		t.Errorf("unexpected version %q, %v", v, err)
+	}
+
+	stats, err := client.Stats()
+	if err != nil || stats[server]["pid"] != "42" || stats[server]["version"] != "1.6.0" {
+		t.Errorf("unexpected stats %v, %v", stats, err)
+	}
+
+	slabs, err := client.StatsServer(server, "slabs")
+	if err != nil || len(slabs) != 1 || slabs["1:chunk_size"] != "96" {
+		t.Errorf("unexpected slabs %v, %v", slabs, err)
+	}
+}
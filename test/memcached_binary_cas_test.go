@@ -0,0 +1,190 @@
+//execute 'go test -v memcached_binary_cas_test.go helpers_test.go'
+
+package test
+
+import (
+	"github.com/ningjh/memcached"
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+)
+
+type casItem struct {
+	value []byte
+	flags uint32
+	cas   uint64
+}
+
+// casServer is a tiny binary protocol server that understands getk, getkq, gatk, gatkq and set with cas.
+func casServer(t *testing.T) net.Listener {
+	var (
+		mu      sync.Mutex
+		items   = make(map[string]*casItem)
+		nextCas uint64
+	)
+
+	return binaryServer(t, func(req *binaryRequest) []binaryResponse {
+		mu.Lock()
+		defer mu.Unlock()
+
+		old := items[req.key]
+
+		switch req.opcode {
+		case 0x0c, 0x0d, 0x23, 0x24: // getk, getkq, gatk, gatkq, the expiration is ignored
+			if old != nil {
+				flags := make([]byte, 4)
+				binary.BigEndian.PutUint32(flags, old.flags)
+				return []binaryResponse{{key: req.key, extras: flags, value: old.value, cas: old.cas}}
+			}
+			if req.opcode == 0x0d || req.opcode == 0x24 {
+				return nil
+			}
+			return []binaryResponse{{status: 0x0001}}
+		case 0x01: // set
+			if req.cas != 0 && old == nil {
+				return []binaryResponse{{status: 0x0001}}
+			}
+			if req.cas != 0 && old.cas != req.cas {
+				return []binaryResponse{{status: 0x0002}}
+			}
+			nextCas++
+			items[req.key] = &casItem{append([]byte(nil), req.value...), binary.BigEndian.Uint32(req.extras[:4]), nextCas}
+			return []binaryResponse{{}}
+		default:
+			return []binaryResponse{{status: 0x0081}}
+		}
+	})
+}
+
+func TestBinaryCas(t *testing.T) {
+	l := casServer(t)
+	defer l.Close()
+
+	client, err := memcached.NewMemcachedClient4B(&config.Config{Servers: []string{l.Addr().String()}, InitConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// large enough to need several reads from the socket
+	blob := bytes.Repeat([]byte("config"), 50000)
+
+	if err = client.Set(&common.Element{Key: "counter", Value: blob, Flags: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := client.Gets("counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(item.Value(), blob) || item.Flags() != 7 || item.Cas() == 0 {
+		t.Fatalf("unexpected item, flags %d, cas %d, %d bytes", item.Flags(), item.Cas(), len(item.Value()))
+	}
+
+	if err = client.Cas(&common.Element{Key: "counter", Value: []byte("1"), Cas: item.Cas()}); err != nil {
+		t.Errorf("Cas: %v", err)
+	}
+
+	// the cas is stale now
+	if err = client.Cas(&common.Element{Key: "counter", Value: []byte("2"), Cas: item.Cas()}); !errors.Is(err, memcached.ErrCASConflict) {
+		t.Errorf("Cas: expected ErrCASConflict, got %v", err)
+	}
+
+	if err = client.Cas(&common.Element{Key: "counter", Value: []byte("2")}); !errors.Is(err, memcached.ErrCASConflict) {
+		t.Errorf("Cas: expected ErrCASConflict without cas, got %v", err)
+	}
+
+	if err = client.Cas(&common.Element{Key: "missing", Value: []byte("2"), Cas: item.Cas()}); !errors.Is(err, memcached.ErrCacheMiss) {
+		t.Errorf("Cas: expected ErrCacheMiss, got %v", err)
+	}
+
+	if err = client.Cas(&common.Element{Key: "missing", Value: []byte("2")}); !errors.Is(err, memcached.ErrCacheMiss) {
+		t.Errorf("Cas: expected ErrCacheMiss without cas, got %v", err)
+	}
+
+	items, err := client.GetsArray([]string{"counter", "missing", "counter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 1 || string(items["counter"].Value()) != "1" || items["counter"].Cas() == item.Cas() {
+		t.Errorf("unexpected items %+v", items)
+	}
+}
+
+func TestBinarySetIgnoresCas(t *testing.T) {
+	l := casServer(t)
+	defer l.Close()
+
+	client, err := memcached.NewMemcachedClient4B(&config.Config{Servers: []string{l.Addr().String()}, InitConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = client.Set(&common.Element{Key: "counter", Value: []byte("0")}); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := client.Gets("counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &common.Element{Key: "counter", Value: []byte("1"), Cas: item.Cas()}
+	if err = client.Set(e); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cas of e is stale now, but Set is unconditional
+	e.Value = []byte("2")
+	if err = client.Set(e); err != nil {
+		t.Errorf("Set: %v", err)
+	}
+
+	if err = client.Set(&common.Element{Key: "missing", Value: []byte("3"), Cas: item.Cas()}); err != nil {
+		t.Errorf("Set: %v", err)
+	}
+
+	if item, err = client.Get("counter"); err != nil || string(item.Value()) != "2" {
+		t.Errorf("unexpected item %v, %v", item, err)
+	}
+}
+
+func TestBinaryGetAndTouch(t *testing.T) {
+	l := casServer(t)
+	defer l.Close()
+
+	client, err := memcached.NewMemcachedClient4B(&config.Config{Servers: []string{l.Addr().String()}, InitConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = client.Set(&common.Element{Key: "session", Value: []byte("alice")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if item, err := client.GetAndTouch("session", 30); err != nil {
+		t.Error(err)
+	} else if string(item.Value()) != "alice" || item.Cas() == 0 {
+		t.Errorf("unexpected item %+v", item)
+	}
+
+	if _, err = client.GetAndTouch("missing", 30); !errors.Is(err, memcached.ErrCacheMiss) {
+		t.Errorf("expected ErrCacheMiss, got %v", err)
+	}
+
+	items, err := client.GetAndTouchArray([]string{"missing", "session"}, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 1 || string(items["session"].Value()) != "alice" {
+		t.Errorf("unexpected items %+v", items)
+	}
+}
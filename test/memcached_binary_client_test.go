@@ -1,4 +1,7 @@
-//execute 'go test -v memcached_binary_client_test.go'
+//execute 'go test -tags integration -v memcached_binary_client_test.go'
+
+//go:build integration
+
 package test
 
 import (
@@ -9,13 +12,13 @@ import (
 	"testing"
 )
 
-var conf = &config.Config{
+var confB = &config.Config{
 	Servers: []string{"127.0.0.1:11211"},
 }
 
-var clientT, _ = memcached.NewMemcachedClient4B(conf)
+var clientB, _ = memcached.NewMemcachedClient4B(confB)
 
-func TestSet(t *testing.T) {
+func TestBinaryClientSet(t *testing.T) {
 	e := &common.Element{
 		Key:   "test1",
 		Value: []byte("World"),
@@ -27,61 +30,81 @@ func TestSet(t *testing.T) {
 		Value: []byte("10"),
 	}
 
-	if err := clientT.Set(e); err != nil {
+	if err := clientB.Set(e); err != nil {
 		t.Errorf("%s", err)
 	}
 
-	if err := clientT.Set(e2); err != nil {
+	if err := clientB.Set(e2); err != nil {
 		t.Errorf("%s", err)
 	}
 }
 
-func TestAdd(t *testing.T) {
+func TestBinaryClientAdd(t *testing.T) {
 	e := &common.Element{
 		Key:   "test4",
 		Value: []byte("memcached client test4"),
 	}
 
-	if err := clientT.Add(e); err != nil {
+	if err := clientB.Add(e); err != nil {
 		t.Errorf("%s", err)
 	}
 }
 
-func TestReplace(t *testing.T) {
+func TestBinaryClientReplace(t *testing.T) {
 	e := &common.Element{
 		Key:   "test1",
 		Value: []byte("memcached client test1 replace"),
 	}
 
-	if err := clientT.Replace(e); err != nil {
+	if err := clientB.Replace(e); err != nil {
 		t.Errorf("%s", err)
 	}
 }
 
-func TestAppend(t *testing.T) {
+func TestBinaryClientAppend(t *testing.T) {
 	e := &common.Element{
 		Key:   "test1",
 		Value: []byte("_Append"),
 	}
 
-	if err := clientT.Append(e); err != nil {
+	if err := clientB.Append(e); err != nil {
 		t.Errorf("%s", err)
 	}
 }
 
-func TestPrepend(t *testing.T) {
+func TestBinaryClientPrepend(t *testing.T) {
 	e := &common.Element{
 		Key:   "test1",
 		Value: []byte("Prepend_"),
 	}
 
-	if err := clientT.Prepend(e); err != nil {
+	if err := clientB.Prepend(e); err != nil {
 		t.Errorf("%s", err)
 	}
 }
 
-func TestGet(t *testing.T) {
-	if item, err := clientT.Get("test1"); err == nil {
+func TestBinaryClientGet(t *testing.T) {
+	if item, err := clientB.Get("test1"); err == nil {
+		t.Logf("%+v", item)
+		t.Logf("%s", string(item.Value()))
+	} else {
+		t.Errorf("no value")
+	}
+}
+
+func TestBinaryClientGetArray(t *testing.T) {
+	if items, err := clientB.GetArray([]string{"test1", "test2"}); err == nil {
+		for _, v := range items {
+			t.Logf("%+v", v)
+			t.Logf("%s", string(v.Value()))
+		}
+	} else {
+		t.Errorf("no value")
+	}
+}
+
+func TestBinaryClientGets(t *testing.T) {
+	if item, err := clientB.Gets("test1"); err == nil {
 		t.Logf("%+v", item)
 		t.Logf("%s", string(item.Value()))
 	} else {
@@ -89,8 +112,8 @@ func TestGet(t *testing.T) {
 	}
 }
 
-func TestGetArray(t *testing.T) {
-	if items, err := clientT.GetArray([]string{"test1", "test2"}); err == nil {
+func TestBinaryClientGetsArray(t *testing.T) {
+	if items, err := clientB.GetsArray([]string{"test1", "test2"}); err == nil {
 		for _, v := range items {
 			t.Logf("%+v", v)
 			t.Logf("%s", string(v.Value()))
@@ -100,30 +123,64 @@ func TestGetArray(t *testing.T) {
 	}
 }
 
-func TestIncr(t *testing.T) {
-	if v, err := clientT.Incr("test2", 5); err != nil {
+func TestBinaryClientCas(t *testing.T) {
+	item, _ := clientB.Gets("test1")
+
+	e := &common.Element{
+		Key:   "test1",
+		Value: []byte("cas"),
+		Cas:   item.Cas(),
+	}
+
+	if err := clientB.Cas(e); err != nil {
+		t.Errorf("%s", err)
+	}
+}
+
+func TestBinaryClientIncr(t *testing.T) {
+	if v, err := clientB.Incr("test2", 5); err != nil {
 		t.Errorf("%s", err)
 	} else {
 		t.Logf("%d", v)
 	}
 }
 
-func TestDecr(t *testing.T) {
-	if v, err := clientT.Decr("test2", 2); err != nil {
+func TestBinaryClientDecr(t *testing.T) {
+	if v, err := clientB.Decr("test2", 2); err != nil {
 		t.Errorf("%s", err)
 	} else {
 		t.Logf("%d", v)
 	}
 }
 
-func TestTouch(t *testing.T) {
-	if err := clientT.Touch("test2", 500); err != nil {
+func TestBinaryClientTouch(t *testing.T) {
+	if err := clientB.Touch("test2", 500); err != nil {
 		t.Errorf("%s", err)
 	}
 }
 
-func TestDelete(t *testing.T) {
-	if err := clientT.Delete("test1"); err != nil {
+func TestBinaryClientGetAndTouch(t *testing.T) {
+	if item, err := clientB.GetAndTouch("test2", 600); err == nil {
+		t.Logf("%+v", item)
+		t.Logf("%s", string(item.Value()))
+	} else {
+		t.Errorf("no value")
+	}
+}
+
+func TestBinaryClientGetAndTouchArray(t *testing.T) {
+	if items, err := clientB.GetAndTouchArray([]string{"test1", "test2"}, 600); err == nil {
+		for _, v := range items {
+			t.Logf("%+v", v)
+			t.Logf("%s", string(v.Value()))
+		}
+	} else {
+		t.Errorf("no value")
+	}
+}
+
+func TestBinaryClientDelete(t *testing.T) {
+	if err := clientB.Delete("test1"); err != nil {
 		t.Errorf("%s", err)
 	}
 }
\ No newline at end of file
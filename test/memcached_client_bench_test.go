@@ -1,4 +1,7 @@
-//execute 'go test -bench . memcached_client_bench_test.go'
+//execute 'go test -tags integration -bench . memcached_client_bench_test.go'
+
+//go:build integration
+
 package test
 
 import (
@@ -1,4 +1,7 @@
-//execute 'go test -v memcached_client_test.go'
+//execute 'go test -tags integration -v memcached_client_test.go'
+
+//go:build integration
+
 package test
 
 import (
@@ -155,6 +158,26 @@ func TestTouch(t *testing.T) {
 	}
 }
 
+func TestGetAndTouch(t *testing.T) {
+	if item, err := clientT.GetAndTouch("test2", 600); err == nil {
+		t.Logf("%+v", item)
+		t.Logf("%s", string(item.Value()))
+	} else {
+		t.Errorf("no value")
+	}
+}
+
+func TestGetAndTouchArray(t *testing.T) {
+	if items, err := clientT.GetAndTouchArray([]string{"test1", "test2"}, 600); err == nil {
+		for _, v := range items {
+			t.Logf("%+v", v)
+			t.Logf("%s", string(v.Value()))
+		}
+	} else {
+		t.Errorf("no value")
+	}
+}
+
 func TestDelete(t *testing.T) {
 	if err := clientT.Delete("test1"); err != nil {
 		t.Errorf("%s", err)
@@ -0,0 +1,125 @@
+//execute 'go test -v memcached_errors_test.go helpers_test.go'
+
+package test
+
+import (
+	"github.com/ningjh/memcached"
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+
+	"errors"
+	"net"
+	"testing"
+)
+
+// textErrors the canned error line of every text command
+var textErrors = map[string]string{
+	"add":    "NOT_STORED\r\n",
+	"cas":    "EXISTS\r\n",
+	"set":    "SERVER_ERROR object too large for cache\r\n",
+	"delete": "NOT_FOUND\r\n",
+	"incr":   "CLIENT_ERROR cannot increment or decrement non-numeric value\r\n",
+	"touch":  "SERVER_ERROR out of memory storing object\r\n",
+	"get":    "END\r\n",
+	"gets":   "END\r\n",
+}
+
+// binaryErrors the canned status code of every binary opcode
+var binaryErrors = map[uint8]uint16{
+	0x02: 0x0002, // add
+	0x01: 0x0002, // set with cas
+	0x03: 0x0001, // replace
+	0x04: 0x0001, // delete
+	0x05: 0x0006, // increment
+	0x1c: 0x0082, // touch
+	0x0c: 0x0001, // getk
+}
+
+// textErrorServer answers every text command with a canned error line.
+func textErrorServer(t *testing.T) net.Listener {
+	return textServer(t, textTable(textErrors))
+}
+
+// binaryErrorServer answers every binary request with a canned status code.
+func binaryErrorServer(t *testing.T) net.Listener {
+	return binaryServer(t, func(req *binaryRequest) []binaryResponse {
+		status, ok := binaryErrors[req.opcode]
+		if !ok {
+			status = 0x0081
+		}
+		return []binaryResponse{{status: status}}
+	})
+}
+
+func checkErrors(t *testing.T, client memcached.Client, binaryProtocol bool) {
+	e := &common.Element{Key: "test1", Value: []byte("test1"), Cas: 1}
+
+	if err := client.Add(e); !errors.Is(err, memcached.ErrNotStored) {
+		t.Errorf("Add: expected ErrNotStored, got %v", err)
+	}
+
+	if err := client.Cas(e); !errors.Is(err, memcached.ErrCASConflict) {
+		t.Errorf("Cas: expected ErrCASConflict, got %v", err)
+	}
+
+	if err := client.Delete("test1"); !errors.Is(err, memcached.ErrCacheMiss) {
+		t.Errorf("Delete: expected ErrCacheMiss, got %v", err)
+	}
+
+	if _, err := client.Incr("test1", 1); !errors.Is(err, memcached.ErrNonNumeric) {
+		t.Errorf("Incr: expected ErrNonNumeric, got %v", err)
+	}
+
+	var serverErr *memcached.ErrServerError
+	if err := client.Touch("test1", 10); !errors.As(err, &serverErr) {
+		t.Errorf("Touch: expected *ErrServerError, got %v", err)
+	} else if serverErr.Server == "" {
+		t.Errorf("Touch: expected the server address in %v", err)
+	}
+
+	if _, err := client.Get("test1"); !errors.Is(err, memcached.ErrCacheMiss) {
+		t.Errorf("Get: expected ErrCacheMiss, got %v", err)
+	}
+
+	if _, err := client.Get("bad key"); !errors.Is(err, memcached.ErrMalformedKey) {
+		t.Errorf("Get: expected ErrMalformedKey, got %v", err)
+	}
+
+	if err := client.Set(nil); !errors.Is(err, memcached.ErrNilElement) {
+		t.Errorf("Set: expected ErrNilElement, got %v", err)
+	}
+
+	if binaryProtocol {
+		if err := client.Replace(e); !errors.Is(err, memcached.ErrNotStored) {
+			t.Errorf("Replace: expected ErrNotStored, got %v", err)
+		}
+	} else {
+		if err := client.Set(e); !errors.Is(err, memcached.ErrValueTooLarge) {
+			t.Errorf("Set: expected ErrValueTooLarge, got %v", err)
+		}
+	}
+}
+
+func TestTextErrors(t *testing.T) {
+	l := textErrorServer(t)
+	defer l.Close()
+
+	client, err := memcached.New(&config.Config{Servers: []string{l.Addr().String()}, InitConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkErrors(t, client, false)
+}
+
+func TestBinaryErrors(t *testing.T) {
+	l := binaryErrorServer(t)
+	defer l.Close()
+
+	client, err := memcached.New(&config.Config{Servers: []string{l.Addr().String()}, InitConns: 1, TextOrBinary: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkErrors(t, client, true)
+}
@@ -0,0 +1,260 @@
+//execute 'go test -v memcached_meta_test.go helpers_test.go'
+
+package test
+
+import (
+	"github.com/ningjh/memcached"
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type metaItem struct {
+	value []byte
+	flags uint32
+	cas   uint64
+	ttl   int64
+}
+
+// metaServer a small in-memory server that understands mg, ms, md, ma and mn.
+func metaServer(t *testing.T) net.Listener {
+	var mu sync.Mutex
+	var cas uint64
+	items := make(map[string]*metaItem)
+
+	return textServer(t, func(fields []string, value []byte) string {
+		if fields[0] == "mn" {
+			return "MN\r\n"
+		}
+
+		key, args := fields[1], fields[2:]
+		if fields[0] == "ms" {
+			args = args[1:]
+		}
+
+		flag := func(f byte) (string, bool) {
+			for _, a := range args {
+				if a[0] == f {
+					return a[1:], true
+				}
+			}
+			return "", false
+		}
+		_, quiet := flag('q')
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		it := items[key]
+		status := "HD"
+		switch fields[0] {
+		case "mg":
+			if it == nil {
+				status = "EN"
+			}
+		case "ms":
+			if expect, ok := flag('C'); ok && (it == nil || strconv.FormatUint(it.cas, 10) != expect) {
+				status = "EX"
+			} else {
+				cas++
+				it = &metaItem{value: value, cas: cas, ttl: -1}
+				if f, ok := flag('F'); ok {
+					n, _ := strconv.ParseUint(f, 10, 32)
+					it.flags = uint32(n)
+				}
+				if ttl, ok := flag('T'); ok {
+					it.ttl, _ = strconv.ParseInt(ttl, 10, 64)
+				}
+				items[key] = it
+			}
+		case "md":
+			if it == nil {
+				status = "NF"
+			} else {
+				delete(items, key)
+			}
+		case "ma":
+			if it == nil {
+				status = "NF"
+			} else {
+				n, _ := strconv.ParseUint(string(it.value), 10, 64)
+				d := uint64(1)
+				if v, ok := flag('D'); ok {
+					d, _ = strconv.ParseUint(v, 10, 64)
+				}
+				it.value = []byte(strconv.FormatUint(n+d, 10))
+			}
+		}
+
+		var ret []string
+		if it != nil && status != "NF" {
+			for _, a := range args {
+				switch a[0] {
+				case 'c':
+					ret = append(ret, fmt.Sprintf("c%d", it.cas))
+				case 'f':
+					ret = append(ret, fmt.Sprintf("f%d", it.flags))
+				case 't':
+					ret = append(ret, fmt.Sprintf("t%d", it.ttl))
+				case 's':
+					ret = append(ret, fmt.Sprintf("s%d", len(it.value)))
+				case 'l':
+					ret = append(ret, "l3")
+				case 'h':
+					ret = append(ret, "h1")
+				case 'k':
+					ret = append(ret, "k"+key)
+				}
+			}
+		}
+		if o, ok := flag('O'); ok {
+			ret = append(ret, "O"+o)
+		}
+
+		_, withValue := flag('v')
+		switch {
+		case withValue && it != nil && status == "HD":
+			return fmt.Sprintf("VA %d %s\r\n%s\r\n", len(it.value), strings.Join(ret, " "), it.value)
+		case quiet && ((fields[0] == "mg" && status == "EN") || (fields[0] != "mg" && status == "HD")):
+			return ""
+		default:
+			return strings.TrimSpace(status+" "+strings.Join(ret, " ")) + "\r\n"
+		}
+	})
+}
+
+func newMetaClient(t *testing.T, l net.Listener) *memcached.MemcachedClient4T {
+	client, err := memcached.NewMemcachedClient4T(&config.Config{Servers: []string{l.Addr().String()}, InitConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestMetaSetGet(t *testing.T) {
+	l := metaServer(t)
+	defer l.Close()
+
+	client := newMetaClient(t, l)
+	defer client.Close()
+
+	set, err := client.MetaSet("meta", []byte("hello"), common.NewMetaFlags().ClientFlags(7).TTL(60).ReturnCas())
+	if err != nil || set.Status != "HD" || set.Cas == 0 {
+		t.Fatalf("unexpected set %+v, %v", set, err)
+	}
+
+	get, err := client.MetaGet("meta", common.NewMetaFlags().Value().ReturnFlags().ReturnTTL().ReturnCas().
+		ReturnSize().ReturnLastAccess().ReturnHit().ReturnKey().Opaque("42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !get.Hit() || string(get.Value) != "hello" || get.Flags != 7 || get.TTL != 60 || get.Cas != set.Cas ||
+		get.Size != 5 || get.LastAccess != 3 || !get.HitBefore || get.Key != "meta" || get.Opaque != "42" {
+		t.Errorf("unexpected get %+v", get)
+	}
+
+	if _, err = client.MetaSet("meta", []byte("world"), common.NewMetaFlags().CompareCas(set.Cas+100)); !errors.Is(err, memcached.ErrCASConflict) {
+		t.Errorf("expected ErrCASConflict, got %v", err)
+	}
+}
+
+func TestMetaMiss(t *testing.T) {
+	l := metaServer(t)
+	defer l.Close()
+
+	client := newMetaClient(t, l)
+	defer client.Close()
+
+	result, err := client.MetaGet("missing", common.NewMetaFlags().Value())
+	if !errors.Is(err, memcached.ErrCacheMiss) || result == nil || result.Status != "EN" || result.Hit() {
+		t.Errorf("expected a miss, got %+v, %v", result, err)
+	}
+
+	result, err = client.MetaDelete("missing", nil)
+	if !errors.Is(err, memcached.ErrCacheMiss) || result.Status != "NF" {
+		t.Errorf("expected NF, got %+v, %v", result, err)
+	}
+}
+
+func TestMetaQuiet(t *testing.T) {
+	l := metaServer(t)
+	defer l.Close()
+
+	client := newMetaClient(t, l)
+	defer client.Close()
+
+	// the suppressed response is told apart from a slow one by the trailing mn
+	result, err := client.MetaGet("missing", common.NewMetaFlags().Value().NoReply())
+	if !errors.Is(err, memcached.ErrCacheMiss) || result.Status != "EN" {
+		t.Errorf("expected a quiet miss, got %+v, %v", result, err)
+	}
+
+	if result, err = client.MetaSet("quiet", []byte("1"), common.NewMetaFlags().NoReply()); err != nil || result.Status != "HD" {
+		t.Errorf("expected a quiet store, got %+v, %v", result, err)
+	}
+
+	if result, err = client.MetaGet("quiet", common.NewMetaFlags().Value().NoReply()); err != nil || string(result.Value) != "1" {
+		t.Errorf("expected a hit, got %+v, %v", result, err)
+	}
+
+	// the connect must be in sync after the quiet commands
+	if result, err = client.MetaGet("quiet", common.NewMetaFlags().ReturnSize()); err != nil || result.Size != 1 {
+		t.Errorf("unexpected get %+v, %v", result, err)
+	}
+}
+
+func TestMetaArithmeticDeleteNoop(t *testing.T) {
+	l := metaServer(t)
+	defer l.Close()
+
+	client := newMetaClient(t, l)
+	defer client.Close()
+
+	if _, err := client.MetaSet("counter", []byte("10"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.MetaArithmetic("counter", common.NewMetaFlags().Mode('I').Delta(5).Value())
+	if err != nil || string(result.Value) != "15" {
+		t.Errorf("unexpected arithmetic %+v, %v", result, err)
+	}
+
+	if _, err = client.MetaDelete("counter", nil); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = client.MetaGet("counter", nil); !errors.Is(err, memcached.ErrCacheMiss) {
+		t.Errorf("expected ErrCacheMiss, got %v", err)
+	}
+
+	if err = client.MetaNoop(); err != nil {
+		t.Error(err)
+	}
+
+	if err = client.MetaNoopServer("127.0.0.1:1"); !errors.Is(err, memcached.ErrUnknownServer) {
+		t.Errorf("expected ErrUnknownServer, got %v", err)
+	}
+}
+
+func TestMetaMalformedSize(t *testing.T) {
+	l := textServer(t, func(fields []string, data []byte) string {
+		return "VA -1\r\n"
+	})
+	defer l.Close()
+
+	client := newMetaClient(t, l)
+	defer client.Close()
+
+	if _, err := client.MetaGet("meta", common.NewMetaFlags().Value()); !errors.Is(err, memcached.ErrMalformedResponse) {
+		t.Errorf("expected ErrMalformedResponse, got %v", err)
+	}
+}
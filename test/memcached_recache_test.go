@@ -0,0 +1,340 @@
+//execute 'go test -v memcached_recache_test.go helpers_test.go'
+
+package test
+
+import (
+	"github.com/ningjh/memcached"
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type recacheItem struct {
+	value   []byte
+	cas     uint64
+	expires time.Time
+	stale   bool
+	won     bool
+}
+
+// recacheServer an in-memory server with the win token semantics of mg (N, R, W, X, Z), ms C and md I.
+func recacheServer(t *testing.T) net.Listener {
+	var mu sync.Mutex
+	var cas uint64
+	items := make(map[string]*recacheItem)
+
+	return textServer(t, func(fields []string, value []byte) string {
+		if fields[0] == "mn" {
+			return "MN\r\n"
+		}
+
+		key, args := fields[1], fields[2:]
+		if fields[0] == "ms" {
+			args = args[1:]
+		}
+
+		flag := func(f byte) (string, bool) {
+			for _, a := range args {
+				if a[0] == f {
+					return a[1:], true
+				}
+			}
+			return "", false
+		}
+		seconds := func(f byte) time.Duration {
+			v, _ := flag(f)
+			n, _ := strconv.Atoi(v)
+			return time.Duration(n) * time.Second
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		it := items[key]
+		if it != nil && time.Now().After(it.expires) {
+			delete(items, key)
+			it = nil
+		}
+
+		var response string
+		switch fields[0] {
+		case "mg":
+			var ret []string
+			if it == nil {
+				if _, ok := flag('N'); !ok {
+					response = "EN\r\n"
+					break
+				}
+				cas++
+				it = &recacheItem{cas: cas, expires: time.Now().Add(seconds('N')), won: true}
+				items[key] = it
+				ret = append(ret, "W")
+			} else {
+				_, recache := flag('R')
+				if it.stale || it.won || (recache && time.Until(it.expires) < seconds('R')) {
+					if it.won {
+						ret = append(ret, "Z")
+					} else {
+						it.won = true
+						ret = append(ret, "W")
+					}
+				}
+				if it.stale {
+					ret = append(ret, "X")
+				}
+			}
+			ret = append(ret, fmt.Sprintf("c%d", it.cas))
+			response = fmt.Sprintf("VA %d %s\r\n%s\r\n", len(it.value), strings.Join(ret, " "), it.value)
+		case "ms":
+			if v, ok := flag('C'); ok && (it == nil || v != strconv.FormatUint(it.cas, 10)) {
+				response = "EX\r\n"
+				break
+			}
+			cas++
+			items[key] = &recacheItem{value: value, cas: cas, expires: time.Now().Add(seconds('T'))}
+			response = "HD\r\n"
+		case "md":
+			if it == nil {
+				response = "NF\r\n"
+				break
+			}
+			if v, ok := flag('C'); ok && v != strconv.FormatUint(it.cas, 10) {
+				response = "EX\r\n"
+				break
+			}
+			if _, ok := flag('I'); ok {
+				it.stale, it.won = true, false
+			} else {
+				delete(items, key)
+			}
+			if _, ok := flag('q'); !ok {
+				response = "HD\r\n"
+			}
+		}
+
+		return response
+	})
+}
+
+func newRecacheClient(t *testing.T, l net.Listener) *memcached.MemcachedClient4T {
+	client, err := memcached.NewMemcachedClient4T(&config.Config{Servers: []string{l.Addr().String()}, InitConns: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// herd call GetOrRecache from n goroutines at once and return the values they got
+func herd(t *testing.T, client *memcached.MemcachedClient4T, n int, loader memcached.Loader) []string {
+	values := make([]string, n)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			v, err := client.GetOrRecache("hot", 60, loader)
+			if err != nil {
+				t.Error(err)
+			}
+			values[i] = string(v)
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	return values
+}
+
+func TestGetOrRecacheMiss(t *testing.T) {
+	l := recacheServer(t)
+	defer l.Close()
+
+	client := newRecacheClient(t, l)
+	defer client.Close()
+
+	var calls int32
+	loader := func(ctx context.Context, key string) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return []byte("fresh"), nil
+	}
+
+	for _, v := range herd(t, client, 20, loader) {
+		if v != "fresh" {
+			t.Errorf("expected fresh, got %q", v)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("loader called %d times, expected once", calls)
+	}
+}
+
+// the losers keep waiting for a slow winner instead of calling loader themselves
+func TestGetOrRecacheSlowWinner(t *testing.T) {
+	l := recacheServer(t)
+	defer l.Close()
+
+	client := newRecacheClient(t, l)
+	defer client.Close()
+
+	var calls int32
+	loader := func(ctx context.Context, key string) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(1500 * time.Millisecond)
+		return []byte("slow"), nil
+	}
+
+	for _, v := range herd(t, client, 5, loader) {
+		if v != "slow" {
+			t.Errorf("expected slow, got %q", v)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("loader called %d times, expected once", calls)
+	}
+
+	// a loser gives up when its context is done
+	if _, err := client.MetaDelete("hot", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	go client.GetOrRecache("hot", 60, func(ctx context.Context, key string) ([]byte, error) {
+		<-release
+		return []byte("late"), nil
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.GetOrRecacheContext(ctx, "hot", 60, loader); err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if calls != 1 {
+		t.Errorf("loader called %d times, expected once", calls)
+	}
+}
+
+func TestGetOrRecacheStale(t *testing.T) {
+	l := recacheServer(t)
+	defer l.Close()
+
+	client := newRecacheClient(t, l)
+	defer client.Close()
+
+	if _, err := client.MetaSet("hot", []byte("old"), common.NewMetaFlags().TTL(60)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Invalidate("hot"); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []byte("new"), nil
+	}
+
+	// the losers get the stale value without waiting for the winner
+	done := make(chan []string)
+	go func() { done <- herd(t, client, 10, loader) }()
+
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+
+	stale := 0
+	for _, v := range <-done {
+		if v == "old" {
+			stale++
+		} else if v != "new" {
+			t.Errorf("unexpected value %q", v)
+		}
+	}
+
+	if calls != 1 || stale != 9 {
+		t.Errorf("loader called %d times and %d stale values, expected 1 and 9", calls, stale)
+	}
+
+	if v, err := client.GetOrRecache("hot", 60, loader); err != nil || string(v) != "new" {
+		t.Errorf("expected the recached value, got %q, %v", v, err)
+	}
+}
+
+func TestGetOrRecacheEarly(t *testing.T) {
+	l := recacheServer(t)
+	defer l.Close()
+
+	client := newRecacheClient(t, l)
+	defer client.Close()
+
+	// 2 seconds left is below a tenth of 60 seconds
+	if _, err := client.MetaSet("hot", []byte("old"), common.NewMetaFlags().TTL(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := client.GetOrRecache("hot", 60, func(ctx context.Context, key string) ([]byte, error) {
+		return []byte("new"), nil
+	})
+	if err != nil || string(v) != "new" {
+		t.Errorf("expected an early recache, got %q, %v", v, err)
+	}
+}
+
+func TestGetOrRecacheLoaderError(t *testing.T) {
+	l := recacheServer(t)
+	defer l.Close()
+
+	client := newRecacheClient(t, l)
+	defer client.Close()
+
+	failure := errors.New("backend down")
+	if _, err := client.GetOrRecache("hot", 60, func(ctx context.Context, key string) ([]byte, error) {
+		return nil, failure
+	}); err != failure {
+		t.Fatalf("expected the loader error, got %v", err)
+	}
+
+	// the placeholder was given up, the next caller wins at once
+	v, err := client.GetOrRecache("hot", 60, func(ctx context.Context, key string) ([]byte, error) {
+		return []byte("fresh"), nil
+	})
+	if err != nil || string(v) != "fresh" {
+		t.Errorf("expected fresh, got %q, %v", v, err)
+	}
+}
+
+func TestInvalidateMiss(t *testing.T) {
+	l := recacheServer(t)
+	defer l.Close()
+
+	client := newRecacheClient(t, l)
+	defer client.Close()
+
+	if err := client.Invalidate("missing"); !errors.Is(err, memcached.ErrCacheMiss) {
+		t.Errorf("expected ErrCacheMiss, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+//execute 'go test -v memcached_stats_test.go helpers_test.go'
+
+package test
+
+import (
+	"github.com/ningjh/memcached"
+	"github.com/ningjh/memcached/config"
+
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+var statsGroups = map[string]string{
+	"":         "STAT pid 1\r\nSTAT version 1.6.0\r\nSTAT get_hits 90\r\nSTAT get_misses 10\r\nSTAT evictions 5\r\nSTAT bytes 512\r\nSTAT limit_maxbytes 1024\r\n",
+	"slabs":    "STAT 1:chunk_size 96\r\nSTAT 1:total_pages 3\r\nSTAT 2:chunk_size 120\r\nSTAT active_slabs 2\r\nSTAT total_malloced 3145728\r\n",
+	"items":    "STAT items:1:number 10\r\nSTAT items:1:age 3600\r\nSTAT items:1:evicted 5\r\n",
+	"settings": "STAT maxbytes 1024\r\nSTAT growth_factor 1.25\r\nSTAT evictions on\r\n",
+	"conns":    "STAT 27:addr tcp:127.0.0.1:50000\r\nSTAT 27:state conn_parse_cmd\r\nSTAT 26:addr tcp:0.0.0.0:11211\r\n",
+}
+
+// statsServer answers every stats group with canned statistics.
+func statsServer(t *testing.T) net.Listener {
+	return textServer(t, func(fields []string, data []byte) string {
+		if fields[0] != "stats" {
+			return "ERROR\r\n"
+		}
+		return statsGroups[strings.Join(fields[1:], " ")] + "END\r\n"
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	l1, l2 := statsServer(t), statsServer(t)
+	defer l1.Close()
+	defer l2.Close()
+
+	client, err := memcached.New(&config.Config{Servers: []string{l1.Addr().String(), l2.Addr().String()}, InitConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	snapshot, err := memcached.Snapshot(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snapshot.Servers) != 2 {
+		t.Fatalf("expected 2 servers, got %d", len(snapshot.Servers))
+	}
+
+	s := snapshot.Servers[l1.Addr().String()]
+
+	if s.General.GetHits != 90 || s.General.Version != "1.6.0" || s.ActiveSlabs != 2 {
+		t.Errorf("unexpected general statistics %+v", s)
+	}
+
+	if c := s.Slabs[1]; c == nil || c.ChunkSize != 96 || c.TotalPages != 3 || c.Age != 3600 || c.Evicted != 5 {
+		t.Errorf("unexpected slab class 1 %+v", c)
+	}
+
+	if c := s.Slabs[2]; c == nil || c.ChunkSize != 120 {
+		t.Errorf("unexpected slab class 2 %+v", c)
+	}
+
+	if !s.Settings.Evictions || s.Settings.GrowthFactor != 1.25 || s.Settings.MaxBytes != 1024 {
+		t.Errorf("unexpected settings %+v", s.Settings)
+	}
+
+	if len(s.Conns) != 2 || s.Conns[1].FD != 27 || s.Conns[1].State != "conn_parse_cmd" {
+		t.Errorf("unexpected conns %+v", s.Conns)
+	}
+
+	if total := snapshot.Total(); total.GetHits != 180 || total.LimitMaxbytes != 2048 {
+		t.Errorf("unexpected total %+v", total)
+	}
+
+	slabs := snapshot.TotalSlabs()
+
+	if c := slabs[1]; c == nil || c.ChunkSize != 96 || c.TotalPages != 6 || c.Number != 20 || c.Age != 3600 || c.Evicted != 10 {
+		t.Errorf("unexpected total of slab class 1 %+v", c)
+	}
+
+	if c := slabs[2]; len(slabs) != 2 || c == nil || c.ChunkSize != 120 {
+		t.Errorf("unexpected total of slab class 2 %+v", c)
+	}
+}
+
+func TestRates(t *testing.T) {
+	now := time.Now()
+
+	server := func(hits, misses, evictions, bytes uint64) *memcached.ServerSnapshot {
+		return &memcached.ServerSnapshot{General: memcached.ServerStats{
+			GetHits: hits, GetMisses: misses, Evictions: evictions, Bytes: bytes, LimitMaxbytes: 1000,
+		}}
+	}
+
+	prev := &memcached.ClusterSnapshot{Time: now, Servers: map[string]*memcached.ServerSnapshot{
+		"a": server(100, 100, 10, 0),
+		"b": server(500, 0, 0, 0),
+	}}
+
+	// b restarted, its counters start again from zero
+	cur := &memcached.ClusterSnapshot{Time: now.Add(10 * time.Second), Servers: map[string]*memcached.ServerSnapshot{
+		"a": server(180, 120, 30, 500),
+		"b": server(20, 0, 10, 250),
+	}}
+
+	rates := memcached.Rates(prev, cur)
+
+	if rates.Interval != 10*time.Second {
+		t.Errorf("unexpected interval %v", rates.Interval)
+	}
+
+	if math.Abs(rates.HitRatio-100.0/120.0) > 1e-9 {
+		t.Errorf("unexpected hit ratio %v", rates.HitRatio)
+	}
+
+	if math.Abs(rates.EvictionRate-3) > 1e-9 {
+		t.Errorf("unexpected eviction rate %v", rates.EvictionRate)
+	}
+
+	if math.Abs(rates.MemoryUtilisation-0.375) > 1e-9 {
+		t.Errorf("unexpected memory utilisation %v", rates.MemoryUtilisation)
+	}
+}
@@ -0,0 +1,92 @@
+//execute 'go test -v helpers_test.go'
+
+package parse
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// textServer a scripted text protocol server, it answers version itself and every other command
+// with the response handle returns, nothing for an empty one.
+// The data block of a storage command (set, add, replace, append, prepend, cas, ms) is read and passed as data.
+func textServer(t *testing.T, handle func(fields []string, data []byte) string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(c net.Conn) {
+				defer c.Close()
+
+				r := bufio.NewReader(c)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+
+					fields := strings.Fields(line)
+					if len(fields) == 0 {
+						continue
+					}
+
+					if fields[0] == "version" {
+						c.Write([]byte("VERSION 1.6.0\r\n"))
+						continue
+					}
+
+					var data []byte
+					if size := dataSize(fields); size >= 0 {
+						data = make([]byte, size+2)
+						if _, err := io.ReadFull(r, data); err != nil {
+							return
+						}
+						data = data[:size]
+					}
+
+					if res := handle(fields, data); res != "" {
+						c.Write([]byte(res))
+					}
+				}
+			}(c)
+		}
+	}()
+
+	return l
+}
+
+// dataSize the size of the data block following a storage command, -1 for the other commands
+func dataSize(fields []string) int {
+	var i int
+	switch fields[0] {
+	case "set", "add", "replace", "append", "prepend", "cas":
+		i = 4
+	case "ms":
+		i = 2
+	default:
+		return -1
+	}
+
+	if i >= len(fields) {
+		return -1
+	}
+
+	size, err := strconv.Atoi(fields[i])
+	if err != nil || size < 0 {
+		return -1
+	}
+
+	return size
+}
@@ -1,30 +1,20 @@
-//execute 'go test -bench . text_protocol_parse_bench_test.go'
+//execute 'go test -tags integration -bench . text_protocol_parse_bench_test.go text_protocol_parse_test.go'
+
+//go:build integration
 
 package parse
 
 import (
+    "context"
     "testing"
     "fmt"
-
-    "github.com/ningjh/memcached/pool"
-    "github.com/ningjh/memcached/config"
-    "github.com/ningjh/memcached/parse"
 )
 
-func new() *parse.TextProtocolParse {
-	c := config.New()
-	c.Servers = []string{"127.0.0.1:11211"}
-
-    p, _ := pool.New(c)
-
-	return parse.NewTextProtocolParse(p)	
-}
-
 //BenchmarkSet   50000    62343 ns/op
 func BenchmarkSet(b *testing.B) {
     tpp := new()
 
 	for i := 0; i < b.N; i++ {
-        tpp.Store("set", fmt.Sprintf("test%d", i), uint32(i), 0, 0, []byte("fkjdfoie-=0987843/.,"))
+        tpp.Store(context.Background(), "set", fmt.Sprintf("test%d", i), uint32(i), 0, 0, []byte("fkjdfoie-=0987843/.,"))
 	}
 }
\ No newline at end of file
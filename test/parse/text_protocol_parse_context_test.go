@@ -0,0 +1,77 @@
+//execute 'go test -v text_protocol_parse_context_test.go helpers_test.go'
+
+package parse
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/parse"
+	"github.com/ningjh/memcached/pool"
+)
+
+// silentServer answers 'version' and never replies to any other command.
+func silentServer(t *testing.T) net.Listener {
+	return textServer(t, func(fields []string, data []byte) string { return "" })
+}
+
+func TestStoreContextTimeout(t *testing.T) {
+	l := silentServer(t)
+	defer l.Close()
+
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 1
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpp := parse.NewTextProtocolParse(p, c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = tpp.Store(ctx, "set", "test1", 0, 0, 0, []byte("test1"))
+
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("request was not bounded by the context, took %v", d)
+	}
+}
+
+func TestStoreContextCancel(t *testing.T) {
+	l := silentServer(t)
+	defer l.Close()
+
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 1
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpp := parse.NewTextProtocolParse(p, c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if err = tpp.Store(ctx, "set", "test1", 0, 0, 0, []byte("test1")); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	// the pool must not hand out a connection to a cancelled request
+	if _, err = p.Get(ctx, "test1"); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,123 @@
+//execute 'go test -v text_protocol_parse_gat_test.go helpers_test.go'
+
+package parse
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ningjh/memcached"
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/parse"
+	"github.com/ningjh/memcached/pool"
+)
+
+// gatServer answers 'gat' and 'gats' with one item per key, with a cas for gats, and records the command lines.
+func gatServer(t *testing.T, lines chan<- string) net.Listener {
+	return textServer(t, func(fields []string, data []byte) string {
+		if fields[0] != "gat" && fields[0] != "gats" {
+			return "ERROR\r\n"
+		}
+
+		lines <- strings.Join(fields, " ")
+
+		cas := ""
+		if fields[0] == "gats" {
+			cas = " 42"
+		}
+
+		var b strings.Builder
+		for _, key := range fields[2:] {
+			b.WriteString("VALUE " + key + " 3 5" + cas + "\r\nhello\r\n")
+		}
+		b.WriteString("END\r\n")
+
+		return b.String()
+	})
+}
+
+func TestRetrievalAndTouch(t *testing.T) {
+	lines := make(chan string, 1)
+
+	l := gatServer(t, lines)
+	defer l.Close()
+
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 1
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	tpp := parse.NewTextProtocolParse(p, c)
+
+	items, err := tpp.RetrievalAndTouch(context.Background(), "gats", 30, []string{"k1", "k2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line := <-lines; line != "gats 30 k1 k2" {
+		t.Errorf("unexpected command %q", line)
+	}
+
+	for _, key := range []string{"k1", "k2"} {
+		item, ok := items[key]
+		if !ok {
+			t.Fatalf("missing %s", key)
+		}
+
+		if string(item.Value()) != "hello" || item.Flags() != 3 || item.Cas() != 42 {
+			t.Errorf("unexpected item %+v", item)
+		}
+	}
+}
+
+func TestClientGetAndTouch(t *testing.T) {
+	lines := make(chan string, 1)
+
+	l := gatServer(t, lines)
+	defer l.Close()
+
+	client, err := memcached.NewMemcachedClient4T(&config.Config{Servers: []string{l.Addr().String()}, InitConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if item, err := client.GetAndTouch("k1", 30); err != nil || string(item.Value()) != "hello" || item.Cas() != 0 {
+		t.Errorf("unexpected item %+v, %v", item, err)
+	}
+
+	if line := <-lines; line != "gat 30 k1" {
+		t.Errorf("unexpected command %q", line)
+	}
+
+	if item, err := client.GetsAndTouch("k1", 30); err != nil || item.Cas() != 42 {
+		t.Errorf("unexpected item %+v, %v", item, err)
+	}
+
+	if line := <-lines; line != "gats 30 k1" {
+		t.Errorf("unexpected command %q", line)
+	}
+
+	if items, err := client.GetAndTouchArray([]string{"k1", "k2"}, 30); err != nil || len(items) != 2 {
+		t.Errorf("unexpected items %+v, %v", items, err)
+	}
+
+	if line := <-lines; line != "gat 30 k1 k2" {
+		t.Errorf("unexpected command %q", line)
+	}
+
+	if items, err := client.GetsAndTouchArray([]string{"k1", "k2"}, 30); err != nil || items["k2"].Cas() != 42 {
+		t.Errorf("unexpected items %+v, %v", items, err)
+	}
+
+	if line := <-lines; line != "gats 30 k1 k2" {
+		t.Errorf("unexpected command %q", line)
+	}
+}
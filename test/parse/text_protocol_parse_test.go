@@ -1,8 +1,11 @@
-//execute 'go test -v text_protocol_parse_test.go'
+//execute 'go test -tags integration -v text_protocol_parse_test.go'
+
+//go:build integration
 
 package parse
 
 import (
+    "context"
     "testing"
 
     "github.com/ningjh/memcached/pool"
@@ -22,7 +25,7 @@ func new() *parse.TextProtocolParse {
 func TestSet(t *testing.T) {
     tpp := new()
 
-    err := tpp.Store("set", "test1", 0, 0, 0, []byte("test1"))
+    err := tpp.Store(context.Background(), "set", "test1", 0, 0, 0, []byte("test1"))
     if err != nil {
     	t.Error(err)
     }
@@ -31,7 +34,7 @@ func TestSet(t *testing.T) {
 func TestSet2(t *testing.T) {
 	tpp := new()
 
-	err := tpp.Store("set", "test2", 1, 0, 0, []byte("5"))
+	err := tpp.Store(context.Background(), "set", "test2", 1, 0, 0, []byte("5"))
 	if err != nil {
 		t.Error(err)
 	}
@@ -40,7 +43,7 @@ func TestSet2(t *testing.T) {
 func TestAdd(t *testing.T) {
 	tpp := new()
 
-	err := tpp.Store("add", "add1", 1, 20, 0, []byte("test3 haha"))
+	err := tpp.Store(context.Background(), "add", "add1", 1, 20, 0, []byte("test3 haha"))
 	if err != nil {
 		t.Error(err)
 	}
@@ -49,7 +52,7 @@ func TestAdd(t *testing.T) {
 func TestReplace(t *testing.T) {
 	tpp := new()
 
-	err := tpp.Store("replace", "test1", 1, 0, 0, []byte("replace 3"))
+	err := tpp.Store(context.Background(), "replace", "test1", 1, 0, 0, []byte("replace 3"))
 	if err != nil {
 		t.Error(err)
 	}
@@ -58,7 +61,7 @@ func TestReplace(t *testing.T) {
 func TestAppend(t *testing.T) {
 	tpp := new()
 
-	err := tpp.Store("append", "test1", 1, 0, 0, []byte("_append"))
+	err := tpp.Store(context.Background(), "append", "test1", 1, 0, 0, []byte("_append"))
 	if err != nil {
 		t.Error(err)
 	}
@@ -67,7 +70,7 @@ func TestAppend(t *testing.T) {
 func TestPrepend(t *testing.T) {
 	tpp := new()
 
-	err := tpp.Store("prepend", "test1", 1, 0, 0, []byte("prepend_"))
+	err := tpp.Store(context.Background(), "prepend", "test1", 1, 0, 0, []byte("prepend_"))
 	if err != nil {
 		t.Error(err)
 	}
@@ -76,7 +79,7 @@ func TestPrepend(t *testing.T) {
 func TestCas(t *testing.T) {
 	tpp := new()
 
-	err := tpp.Store("cas", "test1", 1, 0, 0, []byte("prepend_2"))
+	err := tpp.Store(context.Background(), "cas", "test1", 1, 0, 0, []byte("prepend_2"))
 	if err != nil {
 		t.Error(err)
 	}
@@ -87,14 +90,17 @@ func TestGet(t *testing.T) {
 
     var keys = []string{"add1", "test1", "test2", "abcdefg"}
 
-	items := tpp.Retrieval("get", keys)
+	items, err := tpp.Retrieval(context.Background(), "get", keys)
+	if err != nil {
+		t.Error(err)
+	}
 
 	for _, key := range keys {
         item, ok := items[key]
 
         if ok {
 		    t.Logf("%+v", item)
-		    t.Logf(string(item.Value()))
+		    t.Log(string(item.Value()))
         }
 	}
 }
@@ -104,14 +110,17 @@ func TestGets(t *testing.T) {
 
     var keys = []string{"add1", "test1", "test2", "abcdefg"}
 
-	items := tpp.Retrieval("gets", keys)
+	items, err := tpp.Retrieval(context.Background(), "gets", keys)
+	if err != nil {
+		t.Error(err)
+	}
 
 	for _, key := range keys {
         item, ok := items[key]
 
         if ok {
 		    t.Logf("%+v", item)
-		    t.Logf(string(item.Value()))
+		    t.Log(string(item.Value()))
         }
 	}
 }
@@ -119,7 +128,7 @@ func TestGets(t *testing.T) {
 func TestDelete(t *testing.T) {
 	tpp := new()
 
-	if err := tpp.Deletion("test1"); err != nil {
+	if err := tpp.Deletion(context.Background(), "test1"); err != nil {
 		t.Error(err)
 	}
 }
@@ -127,7 +136,7 @@ func TestDelete(t *testing.T) {
 func TestTouch(t *testing.T) {
 	tpp := new()
 
-    if err := tpp.Touch("test111", 5); err != nil {
+    if err := tpp.Touch(context.Background(), "test111", 5); err != nil {
     	t.Error(err)
     }
 }
@@ -135,7 +144,7 @@ func TestTouch(t *testing.T) {
 func TestIncrement(t *testing.T) {
 	tpp := new()
 
-    if value, err := tpp.IncrOrDecr("incr", "test2", 2); err != nil {
+    if value, err := tpp.IncrOrDecr(context.Background(), "incr", "test2", 2); err != nil {
     	t.Error(err)
     } else {
     	t.Logf("%d", value)
@@ -145,7 +154,7 @@ func TestIncrement(t *testing.T) {
 func TestDecrement(t *testing.T) {
 	tpp := new()
 
-    if value, err := tpp.IncrOrDecr("decr", "test23434", 1); err != nil {
+    if value, err := tpp.IncrOrDecr(context.Background(), "decr", "test23434", 1); err != nil {
     	t.Error(err)
     } else {
     	t.Logf("%d", value)
@@ -0,0 +1,62 @@
+//execute 'go test -v helpers_test.go'
+
+package pool
+
+import (
+	"bufio"
+	"net"
+	"sync/atomic"
+	"testing"
+)
+
+// connCount counts the connections of a versionServer.
+type connCount struct {
+	open     int32 // connections open now
+	peak     int32 // most connections open at once
+	accepted int32 // connections accepted so far
+}
+
+// versionServer listen on addr and answer every line with a version reply, count may be nil.
+func versionServer(t *testing.T, addr string, count *connCount) net.Listener {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			if count != nil {
+				atomic.AddInt32(&count.accepted, 1)
+				n := atomic.AddInt32(&count.open, 1)
+				for {
+					p := atomic.LoadInt32(&count.peak)
+					if n <= p || atomic.CompareAndSwapInt32(&count.peak, p, n) {
+						break
+					}
+				}
+			}
+
+			go func(c net.Conn) {
+				if count != nil {
+					defer atomic.AddInt32(&count.open, -1)
+				}
+				defer c.Close()
+
+				r := bufio.NewReader(c)
+				for {
+					if _, err := r.ReadString('\n'); err != nil {
+						return
+					}
+					c.Write([]byte("VERSION 1.6.0\r\n"))
+				}
+			}(c)
+		}
+	}()
+
+	return l
+}
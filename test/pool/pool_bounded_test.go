@@ -0,0 +1,226 @@
+//execute 'go test -v pool_bounded_test.go helpers_test.go'
+
+package pool
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/pool"
+)
+
+func boundedPool(t *testing.T, l net.Listener, maxOpen, maxIdle int, waitTimeout int64) *pool.ConnectionPool {
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 2
+	c.MaxOpenConns = maxOpen
+	c.MaxIdleConns = maxIdle
+	c.WaitTimeout = waitTimeout
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p.(*pool.ConnectionPool)
+}
+
+func TestMaxOpenConnsTimeout(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	p := boundedPool(t, l, 2, 0, 100)
+	defer p.Close()
+
+	server := l.Addr().String()
+
+	c1, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err = p.Get(context.Background(), "key"); err != common.ErrPoolTimeout {
+		t.Errorf("expected ErrPoolTimeout, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("returned after %v, before WaitTimeout", elapsed)
+	}
+
+	// an exhausted pool does not mark the server down
+	if _, err = p.GetNode("key"); err != nil {
+		t.Errorf("the server was marked down: %v", err)
+	}
+
+	stats := p.Stats()[server]
+	if stats.Open != 2 || stats.WaitCount != 1 || stats.WaitDuration < 100*time.Millisecond {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	p.Release(c1)
+	p.Release(c2)
+}
+
+func TestMaxOpenConnsWait(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	p := boundedPool(t, l, 1, 0, 0)
+	defer p.Close()
+
+	conn, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(chan *common.Conn)
+	go func() {
+		c, err := p.Get(context.Background(), "key")
+		if err != nil {
+			t.Error(err)
+		}
+		got <- c
+	}()
+
+	select {
+	case <-got:
+		t.Fatal("a second connection was handed out")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	p.Release(conn)
+
+	select {
+	case c := <-got:
+		if c != conn {
+			t.Error("the waiter did not get the released connection")
+		}
+		p.Release(c)
+	case <-time.After(time.Second):
+		t.Fatal("the waiter was not woken up")
+	}
+
+	// a cancelled wait
+	conn, _ = p.Get(context.Background(), "key")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err = p.Get(ctx, "key"); err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+
+	p.Release(conn)
+}
+
+func TestMaxOpenConnsSpike(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	p := boundedPool(t, l, 3, 0, 0)
+	defer p.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 50; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 20; k++ {
+				conn, err := p.Get(context.Background(), "key")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				time.Sleep(time.Millisecond)
+				p.Release(conn)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&count.peak) > 3 {
+		t.Errorf("%d connections were open at once, MaxOpenConns is 3", atomic.LoadInt32(&count.peak))
+	}
+
+	if stats := p.Stats()[l.Addr().String()]; stats.Open > 3 || stats.WaitCount == 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMaxIdleConns(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	p := boundedPool(t, l, 10, 1, 0)
+	defer p.Close()
+
+	conns := make([]*common.Conn, 5)
+	for k := range conns {
+		conn, err := p.Get(context.Background(), "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns[k] = conn
+	}
+
+	for _, conn := range conns {
+		p.Release(conn)
+	}
+
+	if stats := p.Stats()[l.Addr().String()]; stats.Open != 1 || stats.Idle != 1 {
+		t.Errorf("expected 1 idle connection, got %+v", stats)
+	}
+}
+
+func TestCloseWakesWaiters(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	p := boundedPool(t, l, 1, 0, 0)
+
+	conn, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := p.Get(context.Background(), "key")
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	p.Close()
+
+	select {
+	case err = <-done:
+		if err != common.ErrClientClosed {
+			t.Errorf("expected ErrClientClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the waiter was not woken up by Close")
+	}
+
+	p.Release(conn)
+}
@@ -0,0 +1,165 @@
+//execute 'go test -v pool_close_test.go helpers_test.go'
+
+package pool
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/pool"
+)
+
+func waitFor(cond func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestClose(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	goroutines := runtime.NumGoroutine()
+
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 4
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := p.Get(context.Background(), "test_pool_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a connection in use is closed when it is released
+	p.Release(conn)
+
+	if _, err = p.Get(context.Background(), "test_pool_key"); err != common.ErrClientClosed {
+		t.Errorf("expected %v, got %v", common.ErrClientClosed, err)
+	}
+
+	if !waitFor(func() bool { return atomic.LoadInt32(&count.open) == 0 }) {
+		t.Errorf("%d connections still open", atomic.LoadInt32(&count.open))
+	}
+
+	// the refresh goroutine of the selector must have exited
+	if !waitFor(func() bool { return runtime.NumGoroutine() <= goroutines }) {
+		t.Errorf("goroutines leaked, before %d, after %d", goroutines, runtime.NumGoroutine())
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 4
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := p.Get(context.Background(), "test_pool_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Shutdown(context.Background())
+	}()
+
+	// draining, no more connections are handed out
+	if !waitFor(func() bool {
+		conn, err := p.Get(context.Background(), "test_pool_key")
+		if err == nil {
+			p.Release(conn)
+		}
+		return err == common.ErrClientClosed
+	}) {
+		t.Fatal("the pool still hands out connections")
+	}
+
+	select {
+	case err = <-done:
+		t.Fatalf("Shutdown returned %v before the connection was released", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	p.Release(conn)
+
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return after the connection was released")
+	}
+
+	if !waitFor(func() bool { return atomic.LoadInt32(&count.open) == 0 }) {
+		t.Errorf("%d connections still open", atomic.LoadInt32(&count.open))
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 4
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := p.Get(context.Background(), "test_pool_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err = p.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// the connection in use has been force-closed
+	if _, err = conn.Write([]byte("version\r\n")); err == nil {
+		t.Error("the connection in use is still open")
+	}
+
+	p.Discard(conn)
+
+	if !waitFor(func() bool { return atomic.LoadInt32(&count.open) == 0 }) {
+		t.Errorf("%d connections still open", atomic.LoadInt32(&count.open))
+	}
+}
@@ -0,0 +1,112 @@
+//execute 'go test -v pool_dial_test.go helpers_test.go'
+
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/factory"
+	"github.com/ningjh/memcached/pool"
+)
+
+// blackhole dial "blackhole:*" like a server that never answers the SYN, the others with net.Dialer
+func blackhole(ctx context.Context, network, addr string) (net.Conn, error) {
+	if host, _, _ := net.SplitHostPort(addr); host == "blackhole" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	var d net.Dialer
+	return d.DialContext(ctx, network, addr)
+}
+
+func TestDialTimeout(t *testing.T) {
+	c := &config.Config{Servers: []string{"blackhole:11211"}, Dialer: blackhole, DialTimeout: 100}
+
+	start := time.Now()
+	if _, err := factory.NewConnectionFactory(c).NewTcpConnect(c.Servers[0], 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the dial took %v", elapsed)
+	}
+}
+
+func TestTCPOptions(t *testing.T) {
+	l := versionServer(t, "127.0.0.1:0", nil)
+	defer l.Close()
+
+	noDelay := false
+	c := &config.Config{Servers: []string{l.Addr().String()}, DialTimeout: 1000, KeepAlive: -1, NoDelay: &noDelay}
+
+	conn, err := factory.NewConnectionFactory(c).NewTcpConnect(c.Servers[0], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, ok := conn.Conn.(*net.TCPConn); !ok || !conn.Connected() {
+		t.Errorf("unexpected connection %T", conn.Conn)
+	}
+}
+
+func TestInitTimeout(t *testing.T) {
+	l := versionServer(t, "127.0.0.1:0", nil)
+	defer l.Close()
+
+	c := &config.Config{
+		Servers:                     []string{l.Addr().String(), "blackhole:11211"},
+		InitConns:                   2,
+		NumberOfReplicas:            20,
+		RefreshHashIntervalInSecond: 10,
+		Dialer:                      blackhole,
+		InitTimeout:                 200,
+	}
+
+	start := time.Now()
+	if _, err := pool.New(c); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("pool.New took %v", elapsed)
+	}
+}
+
+func TestParallelPrefill(t *testing.T) {
+	l := versionServer(t, "127.0.0.1:0", nil)
+	defer l.Close()
+
+	// every server is the same listener, reached after a slow dial
+	slow := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		time.Sleep(100 * time.Millisecond)
+
+		var d net.Dialer
+		return d.DialContext(ctx, network, l.Addr().String())
+	}
+
+	c := &config.Config{
+		Servers:                     []string{"a:1", "b:1", "c:1", "d:1", "e:1"},
+		InitConns:                   1,
+		NumberOfReplicas:            20,
+		RefreshHashIntervalInSecond: 10,
+		Dialer:                      slow,
+	}
+
+	start := time.Now()
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("the servers were not dialed in parallel, pool.New took %v", elapsed)
+	}
+}
@@ -0,0 +1,143 @@
+//execute 'go test -bench . pool_health_bench_test.go'
+
+package pool
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/factory"
+	"github.com/ningjh/memcached/pool"
+)
+
+// benchServer answers version lines and binary NOOP requests.
+func benchServer(b *testing.B, binaryProtocol bool) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(c net.Conn) {
+				defer c.Close()
+
+				if !binaryProtocol {
+					r := bufio.NewReader(c)
+					for {
+						if _, err := r.ReadString('\n'); err != nil {
+							return
+						}
+						c.Write([]byte("VERSION 1.6.0\r\n"))
+					}
+				}
+
+				header := make([]byte, 24)
+				for {
+					if _, err := io.ReadFull(c, header); err != nil {
+						return
+					}
+					if _, err := io.CopyN(io.Discard, c, int64(binary.BigEndian.Uint32(header[8:12]))); err != nil {
+						return
+					}
+					header[0] = 0x81
+					c.Write(header)
+				}
+			}(c)
+		}
+	}()
+
+	return l
+}
+
+func benchConn(b *testing.B, binaryProtocol bool) (*common.Conn, func()) {
+	l := benchServer(b, binaryProtocol)
+
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	if binaryProtocol {
+		c.TextOrBinary = 1
+	}
+
+	conn, err := factory.NewConnectionFactory(c).NewTcpConnect(c.Servers[0], 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return conn, func() { conn.Close(); l.Close() }
+}
+
+// BenchmarkCheckout a checkout of a connection used recently, no health check is done
+func BenchmarkCheckout(b *testing.B) {
+	l := benchServer(b, false)
+	defer l.Close()
+
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 1
+
+	p, err := pool.New(c)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer p.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn, err := p.Get(context.Background(), "key")
+		if err != nil {
+			b.Fatal(err)
+		}
+		p.Release(conn)
+	}
+}
+
+// BenchmarkAlive the non-blocking peek done on a connection idle longer than HealthCheckAfter
+func BenchmarkAlive(b *testing.B) {
+	conn, stop := benchConn(b, false)
+	defer stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !conn.Alive() {
+			b.Fatal("not alive")
+		}
+	}
+}
+
+// BenchmarkPingText a version round trip
+func BenchmarkPingText(b *testing.B) {
+	conn, stop := benchConn(b, false)
+	defer stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !conn.Ping() {
+			b.Fatal("ping failed")
+		}
+	}
+}
+
+// BenchmarkPingBinary a NOOP round trip
+func BenchmarkPingBinary(b *testing.B) {
+	conn, stop := benchConn(b, true)
+	defer stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !conn.Ping() {
+			b.Fatal("ping failed")
+		}
+	}
+}
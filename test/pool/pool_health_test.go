@@ -0,0 +1,286 @@
+//execute 'go test -v pool_health_test.go pool_close_test.go helpers_test.go'
+
+package pool
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/factory"
+	"github.com/ningjh/memcached/pool"
+)
+
+// pingServer answers version lines and counts them, it closes every connection accepted when kill is set.
+func pingServer(t *testing.T, versions, kill *int32) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(c net.Conn) {
+				defer c.Close()
+
+				r := bufio.NewReader(c)
+				for {
+					if atomic.LoadInt32(kill) == 1 {
+						return
+					}
+
+					c.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+					line, err := r.ReadString('\n')
+					if ne, ok := err.(net.Error); ok && ne.Timeout() {
+						continue
+					} else if err != nil {
+						return
+					}
+
+					if strings.HasPrefix(line, "version") {
+						atomic.AddInt32(versions, 1)
+					}
+					c.Write([]byte("VERSION 1.6.0\r\n"))
+				}
+			}(c)
+		}
+	}()
+
+	return l
+}
+
+// noopServer answers binary NOOP requests.
+func noopServer(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(c net.Conn) {
+				defer c.Close()
+
+				header := make([]byte, 24)
+				for {
+					if _, err := io.ReadFull(c, header); err != nil {
+						return
+					}
+
+					body := make([]byte, binary.BigEndian.Uint32(header[8:12]))
+					if _, err := io.ReadFull(c, body); err != nil {
+						return
+					}
+
+					if header[1] == 0x0a {
+						header[0] = 0x81
+						c.Write(header)
+					}
+				}
+			}(c)
+		}
+	}()
+
+	return l
+}
+
+func healthConfig(l net.Listener, after int64) *config.Config {
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 1
+	c.HealthCheckAfter = after
+	return c
+}
+
+func TestNoPingBeforeThreshold(t *testing.T) {
+	var versions, kill int32
+
+	l := pingServer(t, &versions, &kill)
+	defer l.Close()
+
+	p, err := pool.New(healthConfig(l, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	for k := 0; k < 100; k++ {
+		conn, err := p.Get(context.Background(), "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Release(conn)
+	}
+
+	if n := atomic.LoadInt32(&versions); n != 0 {
+		t.Errorf("%d pings were sent to connections idle for less than a second", n)
+	}
+}
+
+func TestPingAfterThreshold(t *testing.T) {
+	var versions, kill int32
+
+	l := pingServer(t, &versions, &kill)
+	defer l.Close()
+
+	p, err := pool.New(healthConfig(l, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Release(conn)
+
+	if n := atomic.LoadInt32(&versions); n != 1 {
+		t.Errorf("expected 1 ping, got %d", n)
+	}
+}
+
+func TestStaleConnReplaced(t *testing.T) {
+	var versions, kill int32
+
+	l := pingServer(t, &versions, &kill)
+	defer l.Close()
+
+	p, err := pool.New(healthConfig(l, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// the server closes the idle connection
+	atomic.StoreInt32(&kill, 1)
+	time.Sleep(100 * time.Millisecond)
+	atomic.StoreInt32(&kill, 0)
+
+	conn, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatalf("the stale connection was not replaced: %v", err)
+	}
+
+	if time.Since(conn.CreatedAt) > 50*time.Millisecond {
+		t.Errorf("got the stale connection created at %v", conn.CreatedAt)
+	}
+	p.Release(conn)
+
+	// the server is not marked down
+	if _, err = p.GetNode("key"); err != nil {
+		t.Errorf("the server was marked down: %v", err)
+	}
+}
+
+func TestBinaryPing(t *testing.T) {
+	l := noopServer(t)
+	defer l.Close()
+
+	c := healthConfig(l, 0)
+	c.TextOrBinary = 1
+
+	conn, err := factory.NewConnectionFactory(c).NewTcpConnect(c.Servers[0], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for k := 0; k < 3; k++ {
+		if !conn.Connected() {
+			t.Fatal("the NOOP ping failed")
+		}
+	}
+
+	// the stream is still in sync, nothing is left to read
+	if !conn.Alive() {
+		t.Error("unexpected data after the NOOP replies")
+	}
+}
+
+func TestAliveClosedByServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		<-closed
+		c.Close()
+	}()
+
+	raw, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn := common.NewConn(raw, healthConfig(l, 0), 0)
+	defer conn.Close()
+
+	if !conn.Alive() {
+		t.Fatal("an idle connection is not alive")
+	}
+
+	close(closed)
+
+	if !waitFor(func() bool { return !conn.Alive() }) {
+		t.Error("a connection closed by the server is still alive")
+	}
+}
+
+// the deadline of a bound context must not outlive it, even if no timeout is configured
+func TestContextDeadlineCleared(t *testing.T) {
+	l := versionServer(t, "127.0.0.1:0", nil)
+	defer l.Close()
+
+	raw, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn := common.NewConn(raw, healthConfig(l, 0), 0)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	conn.Bind(ctx)
+	if !conn.Ping() {
+		t.Fatal("the ping with a context failed")
+	}
+	conn.Unbind()
+
+	time.Sleep(100 * time.Millisecond)
+
+	for k := 0; k < 3; k++ {
+		if !conn.Ping() {
+			t.Fatal("the deadline of the context is still set")
+		}
+	}
+}
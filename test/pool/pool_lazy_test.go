@@ -0,0 +1,132 @@
+//execute 'go test -v pool_lazy_test.go helpers_test.go'
+
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/pool"
+)
+
+// downAddr return an address nothing listens on
+func downAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := l.Addr().String()
+	l.Close()
+
+	return addr
+}
+
+func lazyConfig(servers ...string) *config.Config {
+	return &config.Config{
+		Servers:                     servers,
+		InitConns:                   2,
+		NumberOfReplicas:            20,
+		RefreshHashIntervalInSecond: 1,
+		LazyStart:                   true,
+	}
+}
+
+// servedBy return the set of server indexes the keys map to
+func servedBy(p pool.Pool) map[int]bool {
+	served := make(map[int]bool)
+
+	for k := 0; k < 200; k++ {
+		if i, err := p.GetNode("key" + strconv.Itoa(k)); err == nil {
+			served[i] = true
+		}
+	}
+
+	return served
+}
+
+func TestStrictStart(t *testing.T) {
+	up := versionServer(t, "127.0.0.1:0", nil)
+	defer up.Close()
+
+	c := lazyConfig(up.Addr().String(), downAddr(t))
+	c.LazyStart = false
+
+	if _, err := pool.New(c); err == nil {
+		t.Error("expected pool.New to fail on an unreachable server")
+	}
+}
+
+func TestLazyStart(t *testing.T) {
+	up := versionServer(t, "127.0.0.1:0", nil)
+	defer up.Close()
+
+	down := downAddr(t)
+
+	p, err := pool.New(lazyConfig(up.Addr().String(), down))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// the unreachable server is marked down
+	if served := servedBy(p); len(served) != 1 || !served[0] {
+		t.Fatalf("expected every key on the reachable server, got %v", served)
+	}
+
+	conn, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Release(conn)
+
+	// the refresh task adds the server back once it listens
+	l := versionServer(t, down, nil)
+	defer l.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !servedBy(p)[1] {
+		if time.Now().After(deadline) {
+			t.Fatal("the server was not added back")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestMinHealthyServers(t *testing.T) {
+	up := versionServer(t, "127.0.0.1:0", nil)
+	defer up.Close()
+
+	c := lazyConfig(up.Addr().String(), downAddr(t))
+	c.MinHealthyServers = 2
+
+	if _, err := pool.New(c); !errors.Is(err, common.ErrTooFewServers) {
+		t.Errorf("expected ErrTooFewServers, got %v", err)
+	}
+
+	c.MinHealthyServers = 1
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+}
+
+func TestLazyStartNoServer(t *testing.T) {
+	p, err := pool.New(lazyConfig(downAddr(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err = p.Get(context.Background(), "key"); !errors.Is(err, common.ErrNoAvailableServer) {
+		t.Errorf("expected ErrNoAvailableServer, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+//execute 'go test -v pool_reaper_test.go pool_close_test.go helpers_test.go'
+
+package pool
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/pool"
+)
+
+func reaperConfig(l net.Listener) *config.Config {
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 4
+	return c
+}
+
+func TestIdleTimeout(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	c := reaperConfig(l)
+	c.IdleTimeout = 100
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if atomic.LoadInt32(&count.accepted) == 0 {
+		t.Fatal("the pool was not pre-filled")
+	}
+
+	// the reaper closes the idle connections
+	if !waitFor(func() bool { return atomic.LoadInt32(&count.open) == 0 }) {
+		t.Errorf("%d idle connections still open", atomic.LoadInt32(&count.open))
+	}
+
+	if stats := p.(*pool.ConnectionPool).Stats()[c.Servers[0]]; stats.Open != 0 || stats.Idle != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMaxConnLifetime(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	c := reaperConfig(l)
+	c.InitConns = 1
+	c.MaxConnLifetime = 100
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := conn.CreatedAt
+	p.Release(conn)
+
+	time.Sleep(150 * time.Millisecond)
+
+	// the old connection is not handed out again, even before the reaper ran
+	conn, err = p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Release(conn)
+
+	if !conn.CreatedAt.After(first) || time.Since(conn.CreatedAt) > 100*time.Millisecond {
+		t.Errorf("got a connection created at %v, the first one was created at %v", conn.CreatedAt, first)
+	}
+}
+
+func TestMinIdleConns(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	c := reaperConfig(l)
+	c.IdleTimeout = 100
+	c.MinIdleConns = 3
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	prefilled := atomic.LoadInt32(&count.accepted)
+
+	// the expired connections are replaced, the pool keeps 3 idle connections
+	if !waitFor(func() bool { return atomic.LoadInt32(&count.accepted) >= prefilled+3 }) {
+		t.Fatalf("the idle connections were not replaced, %d accepted", atomic.LoadInt32(&count.accepted))
+	}
+
+	if !waitFor(func() bool {
+		stats := p.(*pool.ConnectionPool).Stats()[c.Servers[0]]
+		return stats.Idle == 3 && stats.Open == 3
+	}) {
+		t.Errorf("unexpected stats %+v", p.(*pool.ConnectionPool).Stats()[c.Servers[0]])
+	}
+}
+
+func TestReaperStops(t *testing.T) {
+	var count connCount
+
+	l := versionServer(t, "127.0.0.1:0", &count)
+	defer l.Close()
+
+	goroutines := runtime.NumGoroutine()
+
+	c := reaperConfig(l)
+	c.IdleTimeout = 50
+	c.MinIdleConns = 2
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	p.Close()
+
+	if !waitFor(func() bool { return atomic.LoadInt32(&count.open) == 0 }) {
+		t.Errorf("%d connections still open", atomic.LoadInt32(&count.open))
+	}
+
+	if !waitFor(func() bool { return runtime.NumGoroutine() <= goroutines }) {
+		t.Errorf("goroutines leaked, before %d, after %d", goroutines, runtime.NumGoroutine())
+	}
+}
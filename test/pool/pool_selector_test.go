@@ -0,0 +1,146 @@
+//execute 'go test -v pool_selector_test.go helpers_test.go'
+
+package pool
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/pool"
+	"github.com/ningjh/memcached/selector"
+)
+
+// recordingSelector a rendezvous selector that records the servers marked down
+type recordingSelector struct {
+	*selector.Rendezvous
+	mu     sync.Mutex
+	down   []int
+	closed int
+}
+
+func (s *recordingSelector) Close() error {
+	s.mu.Lock()
+	s.closed++
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *recordingSelector) MarkDown(i int) {
+	s.mu.Lock()
+	s.down = append(s.down, i)
+	s.mu.Unlock()
+
+	s.Rendezvous.MarkDown(i)
+}
+
+func TestPoolSelector(t *testing.T) {
+	up := versionServer(t, "127.0.0.1:0", nil)
+	defer up.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := l.Addr().String()
+	l.Close()
+
+	s := &recordingSelector{Rendezvous: selector.NewRendezvous()}
+
+	c := config.New()
+	c.Servers = []string{down, up.Addr().String()}
+	c.InitConns = 1
+	c.LazyStart = true
+	c.Selector = s
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if len(s.down) != 1 || s.down[0] != 0 {
+		t.Fatalf("expected the unreachable server marked down, got %v", s.down)
+	}
+
+	for k := 0; k < 100; k++ {
+		key := "key" + strconv.Itoa(k)
+
+		if i, err := p.GetNode(key); err != nil || i != 1 {
+			t.Fatalf("%s: got server %d, %v", key, i, err)
+		}
+
+		conn, err := p.Get(context.Background(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conn.Index != 1 {
+			t.Errorf("%s: got a connection to server %d", key, conn.Index)
+		}
+		p.Release(conn)
+	}
+}
+
+func TestPoolClosesSelector(t *testing.T) {
+	l := versionServer(t, "127.0.0.1:0", nil)
+	defer l.Close()
+
+	s := &recordingSelector{Rendezvous: selector.NewRendezvous()}
+
+	c := config.New()
+	c.Servers = []string{l.Addr().String()}
+	c.InitConns = 1
+	c.Selector = s
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Close()
+	p.Close()
+
+	if s.closed != 1 {
+		t.Errorf("the selector was closed %d times", s.closed)
+	}
+}
+
+func TestWeightedServer(t *testing.T) {
+	l := versionServer(t, "127.0.0.1:0", nil)
+	defer l.Close()
+
+	addr := l.Addr().String()
+
+	c := config.New()
+	c.Servers = []string{addr + " weight=2"}
+	c.InitConns = 1
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Release(conn)
+
+	if conn.Server() != addr {
+		t.Errorf("got server %q, expected %q", conn.Server(), addr)
+	}
+
+	if _, ok := p.Stats()[addr]; !ok {
+		t.Errorf("the statistics are not keyed by address: %v", p.Stats())
+	}
+
+	c.Servers = []string{addr + " weight=x"}
+	if _, err = pool.New(c); err == nil {
+		t.Error("expected an error for a malformed weight")
+	}
+}
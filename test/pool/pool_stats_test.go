@@ -0,0 +1,85 @@
+//execute 'go test -v pool_stats_test.go helpers_test.go'
+
+package pool
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ningjh/memcached/common"
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/pool"
+)
+
+func TestPoolStats(t *testing.T) {
+	l := versionServer(t, "127.0.0.1:0", nil)
+	defer l.Close()
+
+	server := l.Addr().String()
+
+	c := config.New()
+	c.Servers = []string{server}
+	c.InitConns = 1
+	c.MaxIdleConns = 1
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if s := p.Stats()[server]; s.Dials != 1 || s.Open != 1 || s.Idle != 1 || s.InUse != 0 {
+		t.Fatalf("unexpected stats after pool.New %+v", s)
+	}
+
+	conns := make([]*common.Conn, 3)
+	for k := range conns {
+		if conns[k], err = p.Get(context.Background(), "key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := p.Stats()[server]
+	if s.InUse != 3 || s.Idle != 0 || s.Open != 3 || s.Dials != 3 || s.AcquireCount != 3 || s.AcquireDuration <= 0 {
+		t.Errorf("unexpected stats with 3 connections in use %+v", s)
+	}
+
+	// one goes back to the pool, one is closed because the pool is full, one is discarded after an error
+	p.Release(conns[0])
+	p.Release(conns[1])
+	p.Discard(conns[2])
+
+	s = p.Stats()[server]
+	if s.InUse != 0 || s.Idle != 1 || s.Open != 1 || s.DiscardedFull != 1 || s.ClosedOnError != 1 {
+		t.Errorf("unexpected stats after release %+v", s)
+	}
+}
+
+func TestPoolStatsDialFailures(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := l.Addr().String()
+	l.Close()
+
+	c := config.New()
+	c.Servers = []string{down}
+	c.InitConns = 1
+	c.LazyStart = true
+
+	p, err := pool.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err = p.GetServer(context.Background(), 0); err == nil {
+		t.Fatal("expected a dial error")
+	}
+
+	if s := p.Stats()[down]; s.Dials != 2 || s.DialFailures != 2 || s.Open != 0 || s.AcquireCount != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
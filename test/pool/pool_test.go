@@ -1,11 +1,13 @@
-//execute 'go test -v pool_test.go'
+//execute 'go test -tags integration -v pool_test.go'
+
+//go:build integration
 
 package pool
 
 import (
+    "context"
     "github.com/ningjh/memcached/pool"
     "github.com/ningjh/memcached/config"
-    "github.com/ningjh/memcached/selector"
     "testing"
 )
 
@@ -22,7 +24,7 @@ func createPool(t *testing.T) *pool.ConnectionPool {
 
     p, err := pool.New(c)
     if err != nil {
-        t.Error("new pool error.")
+        t.Fatal("new pool error.")
     }
 
     t.Logf("%+v\n", p)
@@ -31,44 +33,46 @@ func createPool(t *testing.T) *pool.ConnectionPool {
 }
 
 func TestNewPool(t *testing.T) {
-    createPool(t)
+    createPool(t).Close()
 }
 
 func TestGet(t *testing.T) {
 	p := createPool(t)
+    defer p.Close()
 
-    index, err := selector.SelectServer(servers, key)
+    index, err := p.GetNode(key)
     if err != nil {
     	t.Error("get index error")
     }
 
-    t.Logf("pool len = %d", len(p.Pools[index]))
+    t.Logf("pool len = %d", p.Stats()[servers[index]].Idle)
 
-    _, err = p.Get(key)
+    _, err = p.Get(context.Background(), key)
     if err != nil {
     	t.Error("get gonn error")
     }
 
-    t.Logf("pool len = %d", len(p.Pools[index]))
+    t.Logf("pool len = %d", p.Stats()[servers[index]].Idle)
 }
 
 func TestRelease(t *testing.T) {
     p := createPool(t)
+    defer p.Close()
 
-    index, err := selector.SelectServer(servers, key)
+    index, err := p.GetNode(key)
     if err != nil {
     	t.Error("get index error")
     }
 
-    conn, _ := p.Get(key)
-    conn1, _ := p.Get(key)
+    conn, _ := p.Get(context.Background(), key)
+    conn1, _ := p.Get(context.Background(), key)
 
-    t.Logf("pool len = %d", len(p.Pools[index]))
+    t.Logf("pool len = %d", p.Stats()[servers[index]].Idle)
 
 
-    p.Release(key, conn)
-    t.Logf("pool len = %d", len(p.Pools[index]))
+    p.Release(conn)
+    t.Logf("pool len = %d", p.Stats()[servers[index]].Idle)
 
-    p.Release(key, conn1)
-    t.Logf("pool len = %d", len(p.Pools[index]))
-}
\ No newline at end of file
+    p.Release(conn1)
+    t.Logf("pool len = %d", p.Stats()[servers[index]].Idle)
+}
@@ -1,4 +1,4 @@
-//execute 'go test -bench . consistent_hashing_bench_test.go'
+//execute 'go test -bench . consistent_hashing_bench_test.go consistent_hashing_test.go'
 
 package selector
 
@@ -9,8 +9,6 @@ import(
     "github.com/ningjh/memcached/config"
 )
 
-var servers []string = []string{"127.0.0.1:8080", "127.0.0.1:8081", "127.0.0.1:8082", "127.0.0.1:8083", "127.0.0.1:8084", "127.0.0.1:8085", "127.0.0.1:8086", "127.0.0.1:8087", "127.0.0.1:8088", "127.0.0.1:8089"}
-
 func BenchmarkConsistentAdd(b *testing.B) {
     conf := config.New()
     conf.Servers = servers
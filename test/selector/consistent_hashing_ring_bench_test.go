@@ -0,0 +1,105 @@
+//execute 'go test -bench . consistent_hashing_ring_bench_test.go'
+
+package selector
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/selector"
+)
+
+// 50 servers of 160 virtual nodes
+func benchRing(b *testing.B, ketama bool) (*selector.Consistent, []string) {
+	servers := make([]string, 50)
+	for i := range servers {
+		servers[i] = fmt.Sprintf("10.0.%d.%d:11211", i/250, i%250+1)
+	}
+
+	conf := config.New()
+	conf.Servers = servers
+	conf.NumberOfReplicas = 160
+	conf.Ketama = ketama
+
+	consistent := selector.NewConsistent(conf)
+	if err := consistent.SetServers(servers...); err != nil {
+		b.Fatal(err)
+	}
+
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "user:" + strconv.Itoa(i)
+	}
+
+	return consistent, keys
+}
+
+func BenchmarkRingGet(b *testing.B) {
+	consistent, keys := benchRing(b, false)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		consistent.Get(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkRingGetKetama(b *testing.B) {
+	consistent, keys := benchRing(b, true)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		consistent.Get(keys[i%len(keys)])
+	}
+}
+
+// lookups from every CPU, they take no lock
+func BenchmarkRingGetParallel(b *testing.B) {
+	consistent, keys := benchRing(b, false)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			consistent.Get(keys[i%len(keys)])
+		}
+	})
+}
+
+// lookups while a server is marked down and up again, the ring is copied on each change
+func BenchmarkRingGetDuringChanges(b *testing.B) {
+	consistent, keys := benchRing(b, false)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			consistent.MarkDown(7)
+			consistent.MarkUp(7)
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			consistent.Get(keys[i%len(keys)])
+		}
+	})
+}
+
+func BenchmarkRingMarkDownUp(b *testing.B) {
+	consistent, _ := benchRing(b, false)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		consistent.MarkDown(i % 50)
+		consistent.MarkUp(i % 50)
+	}
+}
@@ -21,7 +21,12 @@ func TestAdd(t *testing.T) {
         consistent.Add(server)
     }
 
-    consistent.Print()
+    consistent.Each(func(i int, server string, up bool) error {
+        if !up {
+            t.Errorf("%s was not added", server)
+        }
+        return nil
+    })
 }
 
 func TestGet(t *testing.T) {
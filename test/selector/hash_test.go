@@ -0,0 +1,144 @@
+//execute 'go test -v hash_test.go'
+
+package selector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/selector"
+)
+
+var hashServers = []string{"10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211", "10.0.0.4:11211", "10.0.0.5:11211", "10.0.0.6:11211", "10.0.0.7:11211", "10.0.0.8:11211"}
+
+var hashFuncs = map[string]selector.HashFunc{
+	"crc32":    selector.CRC32,
+	"fnv1a32":  selector.FNV1a32,
+	"fnv1a64":  selector.FNV1a64,
+	"md5":      selector.MD5,
+	"murmur3":  selector.Murmur3,
+	"xxhash64": selector.XXHash64,
+}
+
+// reference values of the published algorithms (xxhash XXH64 and MurmurHash3 x64_128 seed 0, h1)
+func TestHashVectors(t *testing.T) {
+	tests := []struct {
+		fn   string
+		key  string
+		hash uint64
+	}{
+		{"crc32", "", 0},
+		{"crc32", "abc", 0x352441c2},
+		{"fnv1a32", "", 0x811c9dc5},
+		{"fnv1a32", "a", 0xe40c292c},
+		{"fnv1a64", "", 0xcbf29ce484222325},
+		{"fnv1a64", "a", 0xaf63dc4c8601ec8c},
+		{"md5", "", 0x04b2008fd98c1dd4},
+		{"md5", "hello", 0x762a4bbc2a40415d},
+		{"murmur3", "", 0},
+		{"murmur3", "a", 0x85555565f6597889},
+		{"murmur3", "hello", 0xcbd8a7b341bd9b02},
+		{"murmur3", "the quick brown fox jumps over the lazy dog", 0xbce4e9fee2ad86b3},
+		{"xxhash64", "", 0xef46db3751d8e999},
+		{"xxhash64", "a", 0xd24ec4f1a98c6e5b},
+		{"xxhash64", "abc", 0x44bc2cf5ad770999},
+		{"xxhash64", "the quick brown fox jumps over the lazy dog", 0xed714233c5a9a792},
+	}
+
+	for _, tt := range tests {
+		if h := hashFuncs[tt.fn](tt.key); h != tt.hash {
+			t.Errorf("%s(%q) = %#x, expected %#x", tt.fn, tt.key, h, tt.hash)
+		}
+	}
+}
+
+func hashRing(servers []string, fn selector.HashFunc) *selector.Consistent {
+	conf := config.New()
+	conf.Servers = servers
+	conf.NumberOfReplicas = 160
+	conf.HashFunc = fn
+
+	consistent := selector.NewConsistent(conf)
+	consistent.SetServers(servers...)
+
+	return consistent
+}
+
+func TestHashFuncDistribution(t *testing.T) {
+	const keys = 20000
+
+	// CRC32 and FNV-1a change little on the last bytes, the points of "<server>#<i>" cluster
+	for _, name := range []string{"md5", "murmur3", "xxhash64"} {
+		fn := hashFuncs[name]
+
+		consistent := hashRing(hashServers, fn)
+
+		counts := make([]int, len(hashServers))
+		for k := 0; k < keys; k++ {
+			i, err := consistent.Get("key:" + strconv.Itoa(k))
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts[i]++
+		}
+
+		mean := keys / len(hashServers)
+		for i, n := range counts {
+			if n < mean*8/10 || n > mean*12/10 {
+				t.Errorf("%s: server %d got %d keys, the mean is %d", name, i, n, mean)
+			}
+		}
+	}
+}
+
+// the ring keeps the 64 bits of the points, a truncated ring would pick other servers
+func TestHashFunc64BitPoints(t *testing.T) {
+	type node struct {
+		hash   uint64
+		server int
+	}
+
+	var nodes []node
+	for i, server := range hashServers {
+		for r := 0; r < 160; r++ {
+			nodes = append(nodes, node{selector.XXHash64(fmt.Sprintf("%s#%d", server, r)), i})
+		}
+	}
+	sort.SliceStable(nodes, func(a, b int) bool { return nodes[a].hash < nodes[b].hash })
+
+	consistent := hashRing(hashServers, selector.XXHash64)
+
+	for k := 0; k < 1000; k++ {
+		key := "key:" + strconv.Itoa(k)
+		h := selector.XXHash64(key)
+
+		j := sort.Search(len(nodes), func(j int) bool { return nodes[j].hash > h }) % len(nodes)
+
+		if i, _ := consistent.Get(key); i != nodes[j].server {
+			t.Fatalf("%s: got server %d, expected %d", key, i, nodes[j].server)
+		}
+	}
+}
+
+// Ketama hashes with MD5 whatever the HashFunc
+func TestHashFuncKetama(t *testing.T) {
+	servers := []string{"10.0.1.1:11211", "10.0.1.2:11211", "10.0.1.3:11211", "10.0.1.4:11211", "10.0.1.5:11211"}
+
+	conf := config.New()
+	conf.Servers = servers
+	conf.Ketama = true
+	conf.HashFunc = selector.XXHash64
+
+	consistent := selector.NewConsistent(conf)
+	consistent.SetServers(servers...)
+
+	// server choices of libketama, see ketama_test.go
+	for key, server := range map[string]string{"foo": "10.0.1.2:11211", "bar": "10.0.1.5:11211", "hello": "10.0.1.4:11211"} {
+		if i, _ := consistent.Get(key); servers[i] != server {
+			t.Errorf("%s: got %s, libketama picks %s", key, servers[i], server)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+//execute 'go test -v ketama_test.go'
+
+package selector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/selector"
+)
+
+var ketamaServers = []string{"10.0.1.1:11211", "10.0.1.2:11211", "10.0.1.3:11211", "10.0.1.4:11211", "10.0.1.5:11211"}
+
+// server choices of libketama (ketama_create_continuum, ketama_get_server) for ketamaServers with equal weights
+var ketamaGolden = []struct {
+	key    string
+	server string
+}{
+	{"foo", "10.0.1.2:11211"},
+	{"bar", "10.0.1.5:11211"},
+	{"baz", "10.0.1.2:11211"},
+	{"hello", "10.0.1.4:11211"},
+	{"memcached", "10.0.1.3:11211"},
+	{"12345", "10.0.1.3:11211"},
+	{"user:1", "10.0.1.5:11211"},
+	{"user:2", "10.0.1.5:11211"},
+	{"session:abc", "10.0.1.2:11211"},
+	{"ketama", "10.0.1.3:11211"},
+	// the key hashes to the first point of the server, libketama picks the point equal to the hash
+	{"10.0.1.1:11211-0", "10.0.1.1:11211"},
+	{"10.0.1.4:11211-7", "10.0.1.4:11211"},
+}
+
+func ketamaRing(servers []string) *selector.Consistent {
+	conf := config.New()
+	conf.Servers = servers
+	conf.Ketama = true
+
+	consistent := selector.NewConsistent(conf)
+	for _, server := range servers {
+		consistent.Add(server)
+	}
+
+	return consistent
+}
+
+func TestKetamaGolden(t *testing.T) {
+	consistent := ketamaRing(ketamaServers)
+
+	for _, g := range ketamaGolden {
+		i, err := consistent.Get(g.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ketamaServers[i] != g.server {
+			t.Errorf("%s: got %s, libketama picks %s", g.key, ketamaServers[i], g.server)
+		}
+	}
+}
+
+func TestKetamaOrder(t *testing.T) {
+	// the choices depend on the server names, not on their order in the list
+	reversed := make([]string, len(ketamaServers))
+	for i, server := range ketamaServers {
+		reversed[len(ketamaServers)-1-i] = server
+	}
+
+	a, b := ketamaRing(ketamaServers), ketamaRing(reversed)
+
+	for k := 0; k < 1000; k++ {
+		key := "key" + strconv.Itoa(k)
+		i, _ := a.Get(key)
+		j, _ := b.Get(key)
+
+		if ketamaServers[i] != reversed[j] {
+			t.Fatalf("%s: %s and %s", key, ketamaServers[i], reversed[j])
+		}
+	}
+}
+
+func TestKetamaRemove(t *testing.T) {
+	consistent := ketamaRing(ketamaServers)
+
+	before := make(map[string]int)
+	for k := 0; k < 1000; k++ {
+		key := "key" + strconv.Itoa(k)
+		before[key], _ = consistent.Get(key)
+	}
+
+	consistent.Remove(ketamaServers[2])
+
+	for key, i := range before {
+		j, err := consistent.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if j == 2 || (i != 2 && i != j) {
+			t.Errorf("%s moved from %s to %s", key, ketamaServers[i], ketamaServers[j])
+		}
+	}
+}
@@ -0,0 +1,168 @@
+//execute 'go test -v selector_test.go'
+
+package selector
+
+import (
+	"hash/crc32"
+	"strconv"
+	"testing"
+
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/selector"
+)
+
+var selectorServers = []string{"10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211", "10.0.0.4:11211", "10.0.0.5:11211", "10.0.0.6:11211", "10.0.0.7:11211", "10.0.0.8:11211"}
+
+const selectorKeys = 20000
+
+func selectors() map[string]selector.ServerSelector {
+	conf := config.New()
+	conf.Servers = selectorServers
+	conf.NumberOfReplicas = 160
+
+	ketama := config.New()
+	ketama.Servers = selectorServers
+	ketama.Ketama = true
+
+	return map[string]selector.ServerSelector{
+		"ring":       selector.NewConsistent(conf),
+		"ketama":     selector.NewConsistent(ketama),
+		"modulo":     selector.NewModulo(),
+		"jump":       selector.NewJump(),
+		"rendezvous": selector.NewRendezvous(),
+	}
+}
+
+func pickAll(t *testing.T, s selector.ServerSelector) []int {
+	picks := make([]int, selectorKeys)
+
+	for k := range picks {
+		i, err := s.PickServer("key:" + strconv.Itoa(k))
+		if err != nil {
+			t.Fatal(err)
+		}
+		picks[k] = i
+	}
+
+	return picks
+}
+
+func TestSelectorDistribution(t *testing.T) {
+	for name, s := range selectors() {
+		s.SetServers(selectorServers...)
+
+		counts := make([]int, len(selectorServers))
+		for _, i := range pickAll(t, s) {
+			counts[i]++
+		}
+
+		// CRC32 spreads the "<server>#<i>" points of the ring unevenly
+		tolerance := 2
+		if name == "ring" {
+			tolerance = 5
+		}
+
+		mean := selectorKeys / len(selectorServers)
+		for i, n := range counts {
+			if n < mean*(10-tolerance)/10 || n > mean*(10+tolerance)/10 {
+				t.Errorf("%s: server %d got %d keys, the mean is %d", name, i, n, mean)
+			}
+		}
+	}
+}
+
+func TestSelectorRemap(t *testing.T) {
+	for name, s := range selectors() {
+		s.SetServers(selectorServers...)
+		before := pickAll(t, s)
+
+		s.MarkDown(3)
+		after := pickAll(t, s)
+
+		moved := 0
+		for k := range before {
+			if after[k] == 3 {
+				t.Fatalf("%s: a key is still on the server marked down", name)
+			}
+			if before[k] != after[k] {
+				moved++
+				if before[k] != 3 && name != "modulo" {
+					t.Fatalf("%s: a key moved from server %d to %d", name, before[k], after[k])
+				}
+			}
+		}
+
+		// modulo moves most of the keys, the others only the keys of the server marked down
+		if name == "modulo" && moved < selectorKeys/2 {
+			t.Errorf("%s: only %d keys moved", name, moved)
+		}
+
+		s.MarkUp(3)
+		for k, i := range pickAll(t, s) {
+			if i != before[k] {
+				t.Fatalf("%s: key %d is on server %d after MarkUp, it was on %d", name, k, i, before[k])
+			}
+		}
+	}
+}
+
+func TestSelectorAllDown(t *testing.T) {
+	for name, s := range selectors() {
+		s.SetServers(selectorServers...)
+		s.Each(func(i int, server string, up bool) error {
+			if !up || server != selectorServers[i] {
+				t.Errorf("%s: unexpected server %d %s %v", name, i, server, up)
+			}
+			s.MarkDown(i)
+			return nil
+		})
+
+		if _, err := s.PickServer("key"); err == nil {
+			t.Errorf("%s: expected an error with every server down", name)
+		}
+	}
+}
+
+// the choices of gomemcache's ServerList: crc32.ChecksumIEEE(key) % len(servers)
+func TestModuloGomemcache(t *testing.T) {
+	s := selector.NewModulo()
+	s.SetServers(selectorServers...)
+
+	for k := 0; k < 1000; k++ {
+		key := "key:" + strconv.Itoa(k)
+		i, _ := s.PickServer(key)
+
+		if want := int(crc32.ChecksumIEEE([]byte(key)) % uint32(len(selectorServers))); i != want {
+			t.Fatalf("%s: got server %d, gomemcache picks %d", key, i, want)
+		}
+	}
+}
+
+// lookups run while servers are marked down and up, go test -race checks the ring is published safely
+func TestSelectorConcurrent(t *testing.T) {
+	for name, s := range selectors() {
+		s.SetServers(selectorServers...)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for k := 0; k < 50; k++ {
+				s.MarkDown(k % len(selectorServers))
+				s.MarkUp(k % len(selectorServers))
+			}
+		}()
+
+	lookups:
+		for k := 0; ; k++ {
+			select {
+			case <-done:
+				break lookups
+			default:
+			}
+
+			if _, err := s.PickServer("key:" + strconv.Itoa(k)); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+//execute 'go test -v weight_test.go'
+
+package selector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/ningjh/memcached/config"
+	"github.com/ningjh/memcached/selector"
+)
+
+var weightedServers = []string{"10.0.0.1:11211", "10.0.0.2:11211 weight=2", "10.0.0.3:11211 weight=3", "10.0.0.4:11211 weight=4"}
+
+var weights = []int{1, 2, 3, 4}
+
+func weightedSelectors() map[string]selector.ServerSelector {
+	conf := config.New()
+	conf.Servers = weightedServers
+	conf.NumberOfReplicas = 160
+	// the "<server>#<i>" points of CRC32 are spread too unevenly to check the shares
+	conf.HashFunc = selector.Murmur3
+
+	ketama := config.New()
+	ketama.Servers = weightedServers
+	ketama.Ketama = true
+
+	return map[string]selector.ServerSelector{
+		"ring":       selector.NewConsistent(conf),
+		"ketama":     selector.NewConsistent(ketama),
+		"modulo":     selector.NewModulo(),
+		"jump":       selector.NewJump(),
+		"rendezvous": selector.NewRendezvous(),
+	}
+}
+
+func TestWeightedShare(t *testing.T) {
+	const keys = 20000
+
+	for name, s := range weightedSelectors() {
+		if err := s.SetServers(weightedServers...); err != nil {
+			t.Fatal(err)
+		}
+
+		counts := make([]int, len(weightedServers))
+		for k := 0; k < keys; k++ {
+			i, err := s.PickServer("key:" + strconv.Itoa(k))
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts[i]++
+		}
+
+		for i := 1; i < len(counts); i++ {
+			if counts[i] <= counts[i-1] {
+				t.Errorf("%s: server %d of weight %d got fewer keys than server %d, %v", name, i, weights[i], i-1, counts)
+			}
+		}
+
+		for i, n := range counts {
+			want := keys * weights[i] / 10
+			if n < want*85/100 || n > want*115/100 {
+				t.Errorf("%s: server %d of weight %d got %d keys, expected about %d", name, i, weights[i], n, want)
+			}
+		}
+	}
+}
+
+func TestWeightedRemap(t *testing.T) {
+	for name, s := range weightedSelectors() {
+		if name == "modulo" {
+			continue
+		}
+
+		s.SetServers(weightedServers...)
+
+		before := make([]int, 5000)
+		for k := range before {
+			before[k], _ = s.PickServer("key:" + strconv.Itoa(k))
+		}
+
+		s.MarkDown(2)
+
+		for k, i := range before {
+			j, _ := s.PickServer("key:" + strconv.Itoa(k))
+			if j == 2 || (i != 2 && i != j) {
+				t.Fatalf("%s: key %d moved from server %d to %d", name, k, i, j)
+			}
+		}
+	}
+}
+
+func TestWeightedSetServers(t *testing.T) {
+	for name, s := range weightedSelectors() {
+		if err := s.SetServers("10.0.0.1:11211 weight=0"); err == nil {
+			t.Errorf("%s: expected an error for weight 0", name)
+		}
+	}
+}
+
+// lookups run while the server list grows and shrinks, go test -race checks a lookup never sees half a list
+func TestWeightedResize(t *testing.T) {
+	for name, s := range weightedSelectors() {
+		s.SetServers(weightedServers...)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for k := 0; k < 50; k++ {
+				s.SetServers(weightedServers[:1+k%len(weightedServers)]...)
+			}
+		}()
+
+	lookups:
+		for k := 0; ; k++ {
+			select {
+			case <-done:
+				break lookups
+			default:
+			}
+
+			if i, err := s.PickServer("key:" + strconv.Itoa(k)); err != nil || i < 0 || i >= len(weightedServers) {
+				t.Fatalf("%s: picked %d, %v", name, i, err)
+			}
+		}
+	}
+}